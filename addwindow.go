@@ -1,63 +1,51 @@
 package main
 
 import (
+	"strconv"
+
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
-)
 
-const (
-	addInputBoxID rectID = iota
-	addDecCountID
-	addIncCountID
-	addDecWorkID
-	addIncWorkID
-	addIncRestID
-	addDecRestID
-	addAddBtnID
+	"todo/ui"
 )
 
+const maxTaskNameLength = 100
+
+// Picked under the work length in one click
+var workLengthChips = []int{15, 25, 45, 50}
+
 type addWindow struct {
 	active   bool
 	dirty    bool
 	canvas   *ebiten.Image
 	position point
 
-	rect              rectLayout
-	titleRect         rectLayout
-	inputBoxRect      rectLayout
-	addBtnRect        rectLayout
-	nameInputSelected bool
-	nameInput         textBox
-	blinkTimer        int
-
-	countRect    rectLayout
-	incCountRect rectangle
-	decCountRect rectangle
-	countValue   int
-	countBuf     [3]rune
-	countText    string
-
-	workLengthRect    rectLayout
-	incWorkLengthRect rectangle
-	decWorkLengthRect rectangle
-	workLengthValue   int
-	workLengthBuf     [3]rune
-	workLengthText    string
-
-	restLengthRect    rectLayout
-	incRestLengthRect rectangle
-	decRestLengthRect rectangle
-	restLengthValue   int
-	restLengthBuf     [3]rune
-	restLengthText    string
-
-	elements rectArray
+	rect rectLayout
+
+	widgets    ui.Root
+	title      ui.Label
+	nameInput  ui.TextInput
+	preset     ui.Stepper
+	count      ui.Stepper
+	workLength ui.Stepper
+	restLength ui.Stepper
+	chips      []*ui.Button
+	// Only the optional target is asked for the stopwatches
+	targetLabel ui.Label
+	addBtn      ui.Button
+
+	presetIndex     int
+	countValue      int
+	workLengthValue int
+	restLengthValue int
+
+	// Hint based on the archived tasks similar to the current name
+	suggestBtn ui.Button
+	suggestion estimateSuggestion
 
 	// resources
 	font          *Font
 	rectOutline   *ebiten.Image
 	outlineConstr constraint
-	bgFill        *ebiten.Image
 }
 
 func (a *addWindow) init(font *Font, outline *ebiten.Image) {
@@ -65,82 +53,139 @@ func (a *addWindow) init(font *Font, outline *ebiten.Image) {
 	const addWindowNoPadding = 0
 	const addWindowMargin = 20
 	AddSignalListener(todoAddBtnPressed, a)
-	a.elements.init(a, 10)
 
 	a.rect = newRectLayout(rectangle{
 		x:      0,
 		y:      0,
 		width:  300,
-		height: 300,
+		height: 372,
 	})
-	a.position = point{
-		windowWidth/2 - 150,
-		windowHeight/2 - 150,
-	}
 	a.rect.cut(rectCutUp, addWindowPadding, 0)
 	a.rect.cut(rectCutDown, addWindowPadding, 0)
 
-	a.titleRect = a.rect.cut(rectCutUp, textSize, 15)
-	a.titleRect.cut(rectCutLeft, addWindowPadding, 0)
-	a.titleRect.cut(rectCutRight, addWindowPadding, 0)
+	titleRect := a.rect.cut(rectCutUp, textSize, 15)
+	titleRect.cut(rectCutLeft, addWindowPadding, 0)
+	titleRect.cut(rectCutRight, addWindowPadding, 0)
 
-	a.inputBoxRect = a.rect.cut(rectCutUp, 30, addWindowPadding)
-	a.inputBoxRect.cut(rectCutLeft, addWindowMargin, 0)
-	a.inputBoxRect.cut(rectCutRight, addWindowMargin, 0)
+	inputBoxRect := a.rect.cut(rectCutUp, 30, addWindowPadding)
+	inputBoxRect.cut(rectCutLeft, addWindowMargin, 0)
+	inputBoxRect.cut(rectCutRight, addWindowMargin, 0)
 
 	tWidth := (a.rect.full.width-(addWindowMargin*2))/2 - addWindowPadding/2
 	advance := font.GlyphAdvance('>', textSize) + 3
-	a.countRect = a.rect.cut(rectCutUp, 60, addWindowPadding)
+
+	presetRect := a.rect.cut(rectCutUp, 30, addWindowPadding)
+	presetRect.cut(rectCutLeft, addWindowMargin, 0)
+	presetRect.cut(rectCutRight, addWindowMargin, 0)
+	countRect := a.rect.cut(rectCutUp, 60, addWindowPadding)
 	{
 		toCut := (a.rect.full.width - tWidth) / 2
-		a.countRect.cut(rectCutLeft, toCut, 0)
-		a.countRect.cut(rectCutRight, toCut, 0)
-		a.countRect = newRectLayout(a.countRect.remaining)
-		a.decCountRect = a.countRect.cut(rectCutLeft, advance, 0).remaining
-		a.incCountRect = a.countRect.cut(rectCutRight, advance, 0).remaining
+		countRect.cut(rectCutLeft, toCut, 0)
+		countRect.cut(rectCutRight, toCut, 0)
+	}
+	suggestRect := a.rect.cut(rectCutUp, smallTextSize, addWindowPadding)
+	timeSelectRect := a.rect.cut(rectCutUp, 60, addWindowPadding)
+	timeSelectRect.cut(rectCutLeft, addWindowMargin, 0)
+	timeSelectRect.cut(rectCutRight, addWindowMargin, 0)
+	workLengthRect := timeSelectRect.cut(rectCutLeft, tWidth, 0)
+	restLengthRect := timeSelectRect.cut(rectCutRight, tWidth, 0)
+	chipsRect := a.rect.cut(rectCutUp, 22, addWindowPadding)
+	chipsRect.cut(rectCutLeft, addWindowMargin, 0)
+	chipsRect.cut(rectCutRight, addWindowMargin, 0)
+
+	addBtnRect := a.rect.cut(rectCutDown, btnHeight, 0)
+	addBtnRect.cut(rectCutLeft, 75, 0)
+	addBtnRect.cut(rectCutRight, 75, 0)
+
+	a.title.SetBounds(uiRect(rectangle{titleRect.remaining.x, titleRect.remaining.y, titleRect.remaining.width, textSize}))
+	a.nameInput = ui.TextInput{
+		MaxLength: maxTaskNameLength,
+		OnChange: func(text string) {
+			a.suggestion = suggestEstimate(todo.archive.items[:todo.archive.count], text)
+			a.suggestBtn.SetHidden(a.isStopwatch() || a.suggestion.samples == 0)
+		},
+		OnSubmit: func(string) { a.addTask() },
+	}
+	a.nameInput.SetBounds(uiRect(inputBoxRect.remaining))
+	a.preset = ui.Stepper{
+		Value: &a.presetIndex, Min: 0, Max: len(programPresets) - 1, Wrap: true,
+		Format:   func(v int) string { return tr(programPresets[v].name) },
+		Framed:   true,
+		OnChange: func(int) { a.applyPreset() },
+	}
+	a.preset.SetBounds(uiRect(presetRect.remaining))
+	a.count = ui.Stepper{Value: &a.countValue, Min: minSessionCount, Max: maxSessionCount, ShiftStep: 5}
+	a.count.SetBounds(uiRect(countRect.remaining))
+	a.suggestBtn = ui.Button{
+		Size: ui.SizeSmall, Flat: true, Muted: true,
+		OnClick: func() { a.count.Set(a.suggestion.sessions) },
+	}
+	a.suggestBtn.SetBounds(uiRect(suggestRect.remaining))
+	a.workLength = ui.Stepper{
+		Value: &a.workLengthValue, Min: int(minSessionLength), Max: int(maxSessionLength), ShiftStep: 10,
+		Format: func(v int) string {
+			if v == 0 {
+				return "--"
+			}
+			return strconv.Itoa(v)
+		},
+	}
+	a.workLength.SetBounds(uiRect(workLengthRect.full))
+	a.restLength = ui.Stepper{Value: &a.restLengthValue, Min: int(minSessionLength), Max: int(maxSessionLength), ShiftStep: 10}
+	a.restLength.SetBounds(uiRect(restLengthRect.full))
+	a.targetLabel = ui.Label{Align: ui.AlignCenter, Muted: true}
+	a.targetLabel.SetBounds(uiRect(restLengthRect.full))
+	a.addBtn = ui.Button{OnClick: a.addTask}
+	a.addBtn.SetBounds(uiRect(addBtnRect.remaining))
+	a.preset.ArrowWidth = advance
+	for _, s := range []*ui.Stepper{&a.count, &a.workLength, &a.restLength} {
+		s.ArrowWidth = advance
+		s.Size = ui.SizeLarge
+		s.Framed = true
+		s.Editable = true
+		s.Wheel = true
 	}
 	{
-		timeSelectRect := a.rect.cut(rectCutUp, 60, addWindowPadding)
-		timeSelectRect.cut(rectCutLeft, addWindowMargin, 0)
-		timeSelectRect.cut(rectCutRight, addWindowMargin, 0)
-
-		a.workLengthRect = timeSelectRect.cut(rectCutLeft, tWidth, 0)
-		a.decWorkLengthRect = a.workLengthRect.cut(rectCutLeft, advance, 0).remaining
-		a.incWorkLengthRect = a.workLengthRect.cut(rectCutRight, advance, 0).remaining
-		a.restLengthRect = timeSelectRect.cut(rectCutRight, tWidth, 0)
-		a.decRestLengthRect = a.restLengthRect.cut(rectCutLeft, advance, 0).remaining
-		a.incRestLengthRect = a.restLengthRect.cut(rectCutRight, advance, 0).remaining
+		const chipSpacing = 8
+		layout := newRectLayout(chipsRect.remaining)
+		count := float64(len(workLengthChips))
+		chipWidth := (layout.remaining.width - chipSpacing*(count-1)) / count
+		for _, length := range workLengthChips {
+			length := length
+			chip := &ui.Button{Size: ui.SizeSmall, OnClick: func() { a.workLength.Set(length) }}
+			chip.SetBounds(uiRect(layout.cut(rectCutLeft, chipWidth, chipSpacing).full))
+			a.chips = append(a.chips, chip)
+		}
 	}
 
-	a.addBtnRect = a.rect.cut(rectCutDown, btnHeight, 0)
-	a.addBtnRect.cut(rectCutLeft, 75, 0)
-	a.addBtnRect.cut(rectCutRight, 75, 0)
-
-	a.elements.setOffset(a.position)
-	a.elements.add(a.inputBoxRect.remaining, addInputBoxID)
-	a.elements.add(a.incCountRect, addIncCountID)
-	a.elements.add(a.decCountRect, addDecCountID)
-	a.elements.add(a.incWorkLengthRect, addIncWorkID)
-	a.elements.add(a.decWorkLengthRect, addDecWorkID)
-	a.elements.add(a.incRestLengthRect, addIncRestID)
-	a.elements.add(a.decRestLengthRect, addDecRestID)
-	a.elements.add(a.addBtnRect.remaining, addAddBtnID)
-
-	a.workLengthValue = int(minSessionLength)
-	a.restLengthValue = int(minSessionLength)
-	a.formatLength()
-	a.countValue = minSessionCount
-	a.formatCount()
+	a.widgets.Add(&a.title)
+	a.widgets.Add(&a.nameInput)
+	a.widgets.Add(&a.preset)
+	a.widgets.Add(&a.count)
+	a.widgets.Add(&a.suggestBtn)
+	a.widgets.Add(&a.workLength)
+	a.widgets.Add(&a.restLength)
+	a.widgets.Add(&a.targetLabel)
+	for _, chip := range a.chips {
+		a.widgets.Add(chip)
+	}
+	a.widgets.Add(&a.addBtn)
+	a.reset()
 
-	a.dirty = true
-	a.canvas = ebiten.NewImage(int(a.rect.full.width), int(a.rect.full.height))
 	a.font = font
 	a.rectOutline = outline
 	a.outlineConstr = constraint{2, 2, 2, 2}
-	a.bgFill = ebiten.NewImage(1, 1)
-	a.bgFill.Fill(darkBackground3)
+}
 
-	a.nameInput.init(font, textSize)
+// Keeps the window centered when the app is resized,
+// the canvas following the UI scale
+func (a *addWindow) layout() {
+	a.position = point{
+		screenBounds.width/2 - a.rect.full.width/2,
+		screenBounds.height/2 - a.rect.full.height/2,
+	}
+	a.dirty = true
+	a.canvas = resizeCanvas(a.canvas, a.rect.full)
 }
 
 func (a *addWindow) update(mPos point, mLeft bool) {
@@ -151,192 +196,95 @@ func (a *addWindow) update(mPos point, mLeft bool) {
 			FireSignal(todoAddWindowClosed, SignalNoArgs)
 			return
 		}
-		a.elements.update(mPos, mLeft)
-
-		if a.nameInputSelected {
-			previousCharCout := a.nameInput.charCount
-			var runes []rune
-			runes = ebiten.AppendInputChars(runes[:0])
-
-			for _, r := range runes {
-				a.nameInput.AppendChar(r)
-			}
-
-			if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
-				a.nameInput.DeleteChar()
-			}
-
-			currentCharCount := a.nameInput.charCount
-			if previousCharCout != currentCharCount {
-				a.dirty = true
-			}
+		if a.widgets.Update(newUIInput(mPos, mLeft, a.position, a.font)) {
+			a.dirty = true
 		}
 	}
 }
 
 func (a *addWindow) draw(dst *ebiten.Image) {
 	if a.active {
-		fillOpt := ebiten.DrawImageOptions{}
-		fillOpt.CompositeMode = ebiten.CompositeModeSourceOver
-		fillOpt.GeoM.Scale(800, 600)
-		fillOpt.ColorM.Scale(1.0, 1.0, 1.0, 0.5)
-		dst.DrawImage(a.bgFill, &fillOpt)
+		drawModalBackground(dst)
 
 		// Draw the background
 		rect := a.rect.full.addPoint(a.position)
-		drawRect(dst, rect, darkBackground1)
-		drawImageSlice(dst, rect, a.rectOutline, a.outlineConstr, White)
-		a.elements.highlight(dst)
+		drawRect(dst, rect, theme.Background1)
+		drawImageSlice(dst, rect, a.rectOutline, a.outlineConstr, theme.Text)
 
 		if a.dirty {
 			a.redraw()
 			a.dirty = false
 		}
-		drawImage(dst, a.canvas, a.position, White)
+		drawCanvas(dst, a.canvas, a.position)
 	}
 }
 
+// The labels are translated here for the language to be
+// changed while the window is open
 func (a *addWindow) redraw() {
 	a.canvas.Clear()
-	// Title
-	drawText(a.canvas, textOptions{
-		font: a.font, text: "Add new Task", pos: point{a.titleRect.remaining.x, a.titleRect.remaining.y},
-		size: textSize, clr: White,
-	})
-
-	// Name input box
-	drawImageSlice(a.canvas, a.inputBoxRect.remaining, a.rectOutline, a.outlineConstr, White)
-	if a.nameInputSelected {
-		cursor := a.nameInput.cursor
-		cursor.x += a.inputBoxRect.remaining.x + 2
-		cursor.y += a.inputBoxRect.remaining.y + 5
-		drawRect(a.canvas, cursor, White)
-		drawRect(a.canvas, a.inputBoxRect.remaining, Color{255, 255, 255, 120})
+	a.title.Text = tr("Add new Task")
+	a.nameInput.Placeholder = tr("Name")
+	a.targetLabel.Text = tr("min target")
+	a.addBtn.Text = tr("Add")
+	for i, chip := range a.chips {
+		chip.Text = tr("{n} min", "{n}", strconv.Itoa(workLengthChips[i]))
+		// The one matching the work length stands out
+		chip.Muted = workLengthChips[i] != a.workLengthValue
 	}
-	if a.nameInput.charCount > 0 {
-		drawText(a.canvas, textOptions{
-			font: a.font, text: string(a.nameInput.GetText()),
-			pos:  point{a.inputBoxRect.remaining.x + 2, a.inputBoxRect.remaining.y + 5},
-			size: textSize, clr: White,
-		})
-	} else {
-		drawText(a.canvas, textOptions{
-			font: a.font, text: "Name",
-			pos:  point{a.inputBoxRect.remaining.x + 2, a.inputBoxRect.remaining.y + 5},
-			size: textSize, clr: Color{255, 255, 255, 120},
-		})
+	if a.suggestion.samples > 0 {
+		a.suggestBtn.Text = tr("Similar tasks took ~{sessions} ({past})",
+			"{sessions}", trn("{n} sessions", a.suggestion.sessions),
+			"{past}", trn("{n} past", a.suggestion.samples),
+		)
 	}
-
-	drawSlider(
-		a.canvas,
-		a.workLengthRect.full,
-		a.incWorkLengthRect, a.decWorkLengthRect,
-		a.workLengthText,
-	)
-	drawSlider(
-		a.canvas,
-		a.restLengthRect.full,
-		a.incRestLengthRect, a.decRestLengthRect,
-		a.restLengthText,
-	)
-	drawSlider(
-		a.canvas,
-		a.countRect.full,
-		a.incCountRect, a.decCountRect,
-		a.countText,
-	)
-
-	drawTextBtn(a.canvas, a.addBtnRect.remaining, "Add", textSize)
+	a.widgets.Draw(widgetPainter{dst: a.canvas, font: a.font})
 }
 
 func (a *addWindow) OnSignal(s Signal) {
 	switch s.Kind {
 	case todoAddBtnPressed:
 		a.active = true
+		a.dirty = true
 	}
 }
 
-func (a *addWindow) onClick(userID rectID) {
-	switch userID {
-	case addInputBoxID:
-		a.nameInputSelected = true
-		a.dirty = true
-
-	case addDecCountID:
-		a.countValue -= 1
-		if a.countValue < 1 {
-			a.countValue = 1
-		}
-		a.formatCount()
-		a.dirty = true
-
-	case addIncCountID:
-		a.countValue += 1
-		if a.countValue > 999 {
-			a.countValue = 999
-		}
-		a.formatCount()
-		a.dirty = true
-
-	case addDecWorkID:
-		a.workLengthValue -= 1
-		if a.workLengthValue < 1 {
-			a.workLengthValue = 1
-		}
-		a.formatLength()
-		a.dirty = true
-
-	case addIncWorkID:
-		a.workLengthValue += 1
-		if a.workLengthValue > 999 {
-			a.workLengthValue = 999
-		}
-		a.formatLength()
-		a.dirty = true
-
-	case addDecRestID:
-		a.restLengthValue -= 1
-		if a.restLengthValue < 1 {
-			a.restLengthValue = 1
-		}
-		a.formatLength()
-		a.dirty = true
-
-	case addIncRestID:
-		a.restLengthValue += 1
-		if a.restLengthValue > 999 {
-			a.restLengthValue = 999
-		}
-		a.formatLength()
-		a.dirty = true
-
-	case addAddBtnID:
-		var name string
-		if a.nameInput.charCount == 0 {
-			name = "Unnamed Task"
-		} else {
-			name = string(a.nameInput.GetText())
+func (a *addWindow) addTask() {
+	name := a.nameInput.Text()
+	if name == "" {
+		name = tr("Unnamed Task")
+	}
+	newTask := task{
+		name:            name,
+		sessionRequired: a.countValue,
+		sessionLength:   minute(a.workLengthValue),
+		restLength:      minute(a.restLengthValue),
+		programName:     programPresets[a.presetIndex].name,
+		phases: programPresets[a.presetIndex].build(
+			minute(a.workLengthValue),
+			minute(a.restLengthValue),
+		),
+	}
+	if a.isStopwatch() {
+		newTask = task{
+			name:   name,
+			kind:   taskKindStopwatch,
+			target: minute(a.workLengthValue),
 		}
-		FireSignal(
-			todoTaskAdded,
-			task{
-				name:            name,
-				sessionRequired: a.countValue,
-				sessionLength:   minute(a.workLengthValue),
-				restLength:      minute(a.restLengthValue),
-			},
-		)
-		a.nameInputSelected = false
-		a.workLengthValue = int(minSessionLength)
-		a.restLengthValue = int(minSessionLength)
-		a.formatLength()
-		a.countValue = minSessionCount
-		a.formatCount()
-		a.nameInput.Clear()
-		a.dirty = true
-		a.active = false
-		FireSignal(todoAddWindowClosed, SignalNoArgs)
 	}
+	FireSignal(todoTaskAdded, newTask)
+	a.reset()
+	a.active = false
+	FireSignal(todoAddWindowClosed, SignalNoArgs)
+}
+
+func (a *addWindow) reset() {
+	a.widgets.Blur()
+	a.nameInput.SetText("")
+	a.suggestion = estimateSuggestion{}
+	a.presetIndex = 0
+	a.countValue = minSessionCount
+	a.applyPreset()
 }
 
 func (a *addWindow) isInputHandled(mPos point) bool {
@@ -346,14 +294,24 @@ func (a *addWindow) isInputHandled(mPos point) bool {
 	return r.boundCheck(mPos)
 }
 
-func (a *addWindow) formatLength() {
-	workCount := numberToString(a.workLengthValue, a.workLengthBuf[:])
-	a.workLengthText = string(a.workLengthBuf[:workCount])
-	restCount := numberToString(a.restLengthValue, a.restLengthBuf[:])
-	a.restLengthText = string(a.restLengthBuf[:restCount])
+func (a *addWindow) isStopwatch() bool {
+	return programPresets[a.presetIndex].stopwatch
 }
 
-func (a *addWindow) formatCount() {
-	count := numberToString(a.countValue, a.countBuf[:])
-	a.countText = string(a.countBuf[:count])
+// The stopwatches only have the optional target, which can be none
+func (a *addWindow) applyPreset() {
+	preset := programPresets[a.presetIndex]
+	a.workLengthValue = int(preset.work)
+	a.restLengthValue = int(preset.rest)
+
+	stopwatch := a.isStopwatch()
+	a.workLength.Min = int(minSessionLength)
+	if stopwatch {
+		a.workLength.Min = 0
+	}
+	a.count.SetHidden(stopwatch)
+	a.restLength.SetHidden(stopwatch)
+	a.suggestBtn.SetHidden(stopwatch || a.suggestion.samples == 0)
+	a.targetLabel.SetHidden(!stopwatch)
+	a.dirty = true
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"time"
+	"todo/noise"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// Fade in or out over about 2 seconds
+const ambientFadeStep = 1.0 / 120
+
+// Background noise played during the work sessions,
+// fading out when resting or paused
+type ambientNoise struct {
+	generator *noise.Generator
+	player    *audio.Player
+	volume    float64
+}
+
+var noiseNames = []string{"No noise", "White noise", "Pink noise", "Brown noise", "Rain"}
+
+func (a *ambientNoise) init() {
+	a.generator = noise.NewGenerator(noise.Brown, time.Now().UnixNano())
+}
+
+func (a *ambientNoise) update(working bool) {
+	target := 0.0
+	if working && userSettings.Noise > 0 && userSettings.Sound {
+		target = float64(userSettings.NoiseVolume) / 100
+		color := noise.Color(userSettings.Noise - 1)
+		if a.generator.Color() != color {
+			a.generator.SetColor(color)
+		}
+	}
+
+	switch {
+	case a.volume < target:
+		a.volume = minFloat(a.volume+ambientFadeStep, target)
+	case a.volume > target:
+		a.volume = maxFloat(a.volume-ambientFadeStep, target)
+	}
+
+	if a.player == nil {
+		if a.volume == 0 || audioContext == nil {
+			return
+		}
+		player, err := audio.NewPlayer(audioContext, a.generator)
+		if err != nil {
+			return
+		}
+		a.player = player
+	}
+	a.player.SetVolume(a.volume)
+	switch {
+	case a.volume == 0 && a.player.IsPlaying():
+		a.player.Pause()
+	case a.volume > 0 && !a.player.IsPlaying():
+		a.player.Play()
+	}
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
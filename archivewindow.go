@@ -1,6 +1,8 @@
 package main
 
 import (
+	"strconv"
+
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
@@ -18,11 +20,9 @@ type (
 		font          *Font
 		rectOutline   *ebiten.Image
 		outlineConstr constraint
-		bgFill        *ebiten.Image
 
 		items []archiveItem
 		count int
-		cap   int
 	}
 
 	archiveItem struct {
@@ -31,27 +31,39 @@ type (
 		nameRect         rectangle
 		textPosition     point
 		finishedRect     rectangle
+		accuracyRect     rectangle
 		archivedDateRect rectangle
+		restoreRect      rectangle
+		// The full name when it had to be cut
+		tooltip string
 	}
 )
 
 func (a *archiveWindow) init(font *Font, outline *ebiten.Image) {
-	const archiveWindowPadding = 10
-	const addWindowMargin = 50
-
 	AddSignalListener(todoArchiveBtnPressed, a)
 	a.items = make([]archiveItem, initialTaskCap)
 
 	a.active = false
+	a.font = font
+	a.rectOutline = outline
+	a.outlineConstr = constraint{2, 2, 2, 2}
+}
+
+// The window grows with the app, the canvas being
+// allocated again when the size changed
+func (a *archiveWindow) layout() {
+	const archiveWindowPadding = 10
+	const addWindowMargin = 50
+
 	a.rect = newRectLayout(rectangle{
 		x:      0,
 		y:      0,
-		width:  windowWidth - 200,
-		height: windowHeight - 100,
+		width:  screenBounds.width - 200,
+		height: screenBounds.height - 100,
 	})
 	a.position = point{
-		windowWidth/2 - a.rect.full.width/2,
-		windowHeight/2 - a.rect.full.height/2,
+		screenBounds.width/2 - a.rect.full.width/2,
+		screenBounds.height/2 - a.rect.full.height/2,
 	}
 	a.rect.cut(rectCutUp, archiveWindowPadding, 0)
 	a.rect.cut(rectCutDown, archiveWindowPadding, 0)
@@ -66,13 +78,12 @@ func (a *archiveWindow) init(font *Font, outline *ebiten.Image) {
 	a.listRect.cut(rectCutLeft, addWindowMargin, 0)
 	a.listRect.cut(rectCutRight, addWindowMargin, 0)
 
+	for i := 0; i < a.count; i += 1 {
+		a.items[i] = a.layoutItem(i)
+	}
+
 	a.dirty = true
-	a.canvas = ebiten.NewImage(int(a.rect.full.width), int(a.rect.full.height))
-	a.font = font
-	a.rectOutline = outline
-	a.outlineConstr = constraint{2, 2, 2, 2}
-	a.bgFill = ebiten.NewImage(1, 1)
-	a.bgFill.Fill(darkBackground3)
+	a.canvas = resizeCanvas(a.canvas, a.rect.full)
 }
 
 func (a *archiveWindow) update(mPos point, mLeft bool) {
@@ -83,31 +94,37 @@ func (a *archiveWindow) update(mPos point, mLeft bool) {
 			FireSignal(todoArchiveWindowClosed, SignalNoArgs)
 			return
 		}
+		for i := 0; i < a.count; i += 1 {
+			item := &a.items[i]
+			if item.tooltip != "" && item.nameRect.boundCheck(relPos) {
+				showTooltip(item.tooltip, item.nameRect.addPoint(a.position))
+			}
+			if mLeft && item.restoreRect.boundCheck(relPos) {
+				FireSignal(todoTaskRestored, SignalInt(i))
+				return
+			}
+		}
 	}
 }
 
 func (a *archiveWindow) draw(dst *ebiten.Image, archivedTasks []task) {
 	if a.active {
-		fillOpt := ebiten.DrawImageOptions{}
-		fillOpt.CompositeMode = ebiten.CompositeModeSourceOver
-		fillOpt.GeoM.Scale(800, 600)
-		fillOpt.ColorM.Scale(1.0, 1.0, 1.0, 0.5)
-		dst.DrawImage(a.bgFill, &fillOpt)
+		drawModalBackground(dst)
 
 		// Draw the background
 		rect := a.rect.full.addPoint(a.position)
-		drawRect(dst, rect, darkBackground1)
-		drawImageSlice(dst, rect, a.rectOutline, a.outlineConstr, White)
+		drawRect(dst, rect, theme.Background1)
+		drawImageSlice(dst, rect, a.rectOutline, a.outlineConstr, theme.Text)
 		// a.elements.highlight(dst)
 
 		rect = a.listRect.remaining.addPoint(a.position)
-		drawRect(dst, rect, darkBackground2)
-		drawImageSlice(dst, rect, a.rectOutline, a.outlineConstr, darkSeparator)
+		drawRect(dst, rect, theme.Background2)
+		drawImageSlice(dst, rect, a.rectOutline, a.outlineConstr, theme.Separator)
 
 		if a.dirty {
 			a.redraw(archivedTasks)
 		}
-		drawImage(dst, a.canvas, a.position, White)
+		drawCanvas(dst, a.canvas, a.position)
 	}
 }
 
@@ -115,18 +132,45 @@ func (a *archiveWindow) redraw(archivedTasks []task) {
 	a.canvas.Clear()
 
 	drawTextCenter(a.canvas, textOptions{
-		font: a.font, text: "Archive", bounds: a.titleRect.remaining,
-		size: largeTextSize, clr: White,
+		font: a.font, text: tr("Archive"), bounds: a.titleRect.remaining,
+		size: largeTextSize, clr: theme.Text,
 	})
 
 	for i := 0; i < a.count; i += 1 {
 		item := &a.items[i]
 		task := &archivedTasks[i]
 
+		nameWidth := item.nameRect.x + item.nameRect.width - item.textPosition[0] - itemPadding
+		name, cut := a.font.truncate(task.name, textSize, nameWidth)
+		item.tooltip = ""
+		if cut {
+			item.tooltip = task.name
+		}
 		drawText(a.canvas, textOptions{
-			font: a.font, text: task.name, pos: item.textPosition,
-			size: textSize, clr: White,
+			font: a.font, text: name, pos: item.textPosition,
+			size: textSize, clr: theme.Text,
+		})
+		finishedText := trn("{done}/{n} sessions", task.sessionRequired, "{done}", strconv.Itoa(task.sessionCompleted))
+		ratioText := formatPercent(task.estimateRatio())
+		if task.kind == taskKindStopwatch {
+			finishedText = tr("{time} tracked", "{time}", formatDuration(task.workedTime))
+			if task.target == 0 {
+				ratioText = "-"
+			}
+		}
+		drawTextCenter(a.canvas, textOptions{
+			font: a.font, text: finishedText,
+			bounds: item.finishedRect, size: smallTextSize, clr: theme.MutedText,
+		})
+		drawTextCenter(a.canvas, textOptions{
+			font: a.font, text: ratioText,
+			bounds: item.accuracyRect, size: smallTextSize, clr: theme.Text,
+		})
+		drawTextCenter(a.canvas, textOptions{
+			font: a.font, text: formatDate(task.archivedAt),
+			bounds: item.archivedDateRect, size: smallTextSize, clr: theme.MutedText,
 		})
+		drawTextBtn(a.canvas, item.restoreRect, tr("Restore"), smallTextSize)
 		drawRect(
 			a.canvas,
 			rectangle{
@@ -135,26 +179,36 @@ func (a *archiveWindow) redraw(archivedTasks []task) {
 				item.rect.full.width,
 				1,
 			},
-			darkSeparator,
+			theme.Separator,
 		)
 	}
 	a.dirty = false
 }
 
-func (a *archiveWindow) addItem() {
+func (a *archiveWindow) layoutItem(index int) archiveItem {
 	rect := newRectLayout(rectangle{
 		x:      a.listRect.remaining.x,
-		y:      a.listRect.remaining.y + float64(a.count)*itemHeight,
+		y:      a.listRect.remaining.y + float64(index)*itemHeight,
 		width:  a.listRect.remaining.width,
 		height: itemHeight,
 	})
+	restore := rect.cut(rectCutRight, 80, 0).full
 	item := archiveItem{
-		rect:     rect,
-		nameRect: rect.cut(rectCutLeft, rect.remaining.width-100, 10).full,
+		rect:             rect,
+		restoreRect:      rectangle{restore.x, restore.y + 6, restore.width - 10, restore.height - 12},
+		archivedDateRect: rect.cut(rectCutRight, 60, 0).full,
+		accuracyRect:     rect.cut(rectCutRight, 50, 0).full,
+		finishedRect:     rect.cut(rectCutRight, 90, 0).full,
 	}
+	item.nameRect = rect.cut(rectCutLeft, rect.remaining.width, 10).full
 	item.textPosition = point{item.nameRect.x + itemPadding, item.nameRect.y + (item.nameRect.height-a.font.Ascent(textSize))/2}
-	if a.count > len(a.items) {
-		newSlice := make([]archiveItem, a.cap*2)
+	return item
+}
+
+func (a *archiveWindow) addItem() {
+	item := a.layoutItem(a.count)
+	if a.count >= len(a.items) {
+		newSlice := make([]archiveItem, len(a.items)*2)
 		copy(newSlice[:], a.items[:])
 		a.items = newSlice
 	}
@@ -164,6 +218,14 @@ func (a *archiveWindow) addItem() {
 	a.dirty = true
 }
 
+// The items are only laid out by index, the last one can go
+func (a *archiveWindow) removeItem() {
+	if a.count > 0 {
+		a.count -= 1
+		a.dirty = true
+	}
+}
+
 func (a *archiveWindow) OnSignal(s Signal) {
 	switch s.Kind {
 	case todoArchiveBtnPressed:
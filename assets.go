@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font/opentype"
+)
+
+//go:embed assets
+var embeddedAssets embed.FS
+
+// Every asset is loaded once and shared, a file with the same name
+// in the override directory replacing the embedded one
+type assetManager struct {
+	images map[string]*ebiten.Image
+	fonts  map[string]*opentype.Font
+	errs   []error
+}
+
+var assets = assetManager{
+	images: make(map[string]*ebiten.Image),
+	fonts:  make(map[string]*opentype.Font),
+}
+
+// Next to the settings file, empty by default
+func assetsOverrideDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, settingsDirName, assetsDirName), nil
+}
+
+// Returns the override when there is one, the
+// embedded file otherwise
+func (a *assetManager) readFile(name string) ([]byte, bool, error) {
+	if dir, err := assetsOverrideDir(); err == nil {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return data, true, nil
+		} else if !os.IsNotExist(err) {
+			return nil, true, err
+		}
+	}
+	data, err := embeddedAssets.ReadFile(path.Join(assetsDirName, name))
+	return data, false, err
+}
+
+// A broken override is reported and the embedded
+// asset used instead
+func (a *assetManager) decode(name string, decode func(data []byte) error) {
+	data, overridden, err := a.readFile(name)
+	if err == nil {
+		err = decode(data)
+	}
+	if err != nil && overridden {
+		a.errs = append(a.errs, fmt.Errorf("%s: %w, using the default one", name, err))
+		data, err = embeddedAssets.ReadFile(path.Join(assetsDirName, name))
+		if err == nil {
+			err = decode(data)
+		}
+	}
+	if err != nil {
+		a.errs = append(a.errs, fmt.Errorf("%s: %w", name, err))
+	}
+}
+
+// Never nil, an empty image standing in when the asset can't be
+// loaded so the drawing code doesn't have to care
+func (a *assetManager) image(name string) *ebiten.Image {
+	if img, exist := a.images[name]; exist {
+		return img
+	}
+	var img *ebiten.Image
+	a.decode(name, func(data []byte) error {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err == nil {
+			img = ebiten.NewImageFromImage(decoded)
+		}
+		return err
+	})
+	if img == nil {
+		img = ebiten.NewImage(16, 16)
+	}
+	a.images[name] = img
+	return img
+}
+
+func (a *assetManager) font(name string) *opentype.Font {
+	if tt, exist := a.fonts[name]; exist {
+		return tt
+	}
+	var tt *opentype.Font
+	a.decode(name, func(data []byte) error {
+		var err error
+		tt, err = parseFont(data)
+		return err
+	})
+	if tt == nil {
+		// Nothing can be drawn without it
+		panic(a.errs[len(a.errs)-1])
+	}
+	a.fonts[name] = tt
+	return tt
+}
+
+// Nil when it can't be loaded, the glyphs being
+// looked up in the next fallbacks instead
+func (a *assetManager) fallbackFont(name string) *opentype.Font {
+	var tt *opentype.Font
+	a.decode(name, func(data []byte) error {
+		var err error
+		tt, err = parseFont(data)
+		return err
+	})
+	return tt
+}
+
+// The windows keep the pointers to the images, so the new
+// pixels are copied over the old ones
+func (a *assetManager) reload() {
+	for name, img := range a.images {
+		var next *ebiten.Image
+		a.decode(name, func(data []byte) error {
+			decoded, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return err
+			}
+			if decoded.Bounds().Size() != img.Bounds().Size() {
+				return fmt.Errorf("the size changed, restart to use it")
+			}
+			next = ebiten.NewImageFromImage(decoded)
+			return nil
+		})
+		if next != nil {
+			img.Clear()
+			img.DrawImage(next, nil)
+			next.Dispose()
+		}
+	}
+	for name := range a.fonts {
+		delete(a.fonts, name)
+		a.font(name)
+	}
+}
+
+// The errors since the last call, shown to the user
+func (a *assetManager) takeErrors() []error {
+	errs := a.errs
+	a.errs = nil
+	return errs
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+const (
+	audioSampleRate = 44100
+	// 16 bits stereo
+	audioBytesPerFrame = 4
+)
+
+const (
+	cueWorkEnd soundCue = iota
+	cueRestEnd
+	cueTaskDone
+	cueWarning
+	cueCount
+)
+
+var errUnknownAudioFormat = errors.New("unknown audio format")
+
+type (
+	soundCue int
+
+	// Where the decoded cues end up being played.
+	// The null backend only remembers the last one so
+	// the subsystem can run without an audio device
+	audioBackend interface {
+		load(cue soundCue, pcm []byte)
+		play(cue soundCue, volume float64)
+	}
+
+	audioSystem struct {
+		backend audioBackend
+		err     error
+	}
+
+	ebitenAudio struct {
+		players [cueCount]*audio.Player
+	}
+
+	nullAudio struct {
+		plays  int
+		last   soundCue
+		volume float64
+	}
+
+	// Sine notes played one after the other
+	cueTone struct {
+		notes    []float64
+		noteTime float64
+	}
+)
+
+// Created once and shared, ebiten doesn't allow more than one
+var audioContext *audio.Context
+
+var defaultCueTones = [cueCount]cueTone{
+	cueWorkEnd:  {notes: []float64{880, 660}, noteTime: 0.2},
+	cueRestEnd:  {notes: []float64{660, 880}, noteTime: 0.2},
+	cueTaskDone: {notes: []float64{523.25, 659.25, 783.99, 1046.5}, noteTime: 0.15},
+	cueWarning:  {notes: []float64{440, 440}, noteTime: 0.1},
+}
+
+func (a *audioSystem) init() {
+	AddSignalListener(todoTaskWorkEnded, a)
+	AddSignalListener(todoTaskRestEnded, a)
+	AddSignalListener(todoTaskCompleted, a)
+	AddSignalListener(todoTaskWarning, a)
+	AddSignalListener(todoSettingsChanged, a)
+	a.reload()
+}
+
+// Picks the backend and decodes all the cues again.
+// Cues failing to load fall back to the default tone
+func (a *audioSystem) reload() {
+	if userSettings.Sound {
+		if _, ok := a.backend.(*ebitenAudio); !ok {
+			if audioContext == nil {
+				audioContext = audio.NewContext(audioSampleRate)
+			}
+			a.backend = new(ebitenAudio)
+		}
+	} else {
+		a.backend = new(nullAudio)
+	}
+
+	a.err = nil
+	for cue := soundCue(0); cue < cueCount; cue += 1 {
+		pcm, err := loadCueFile(userSettings.Cues.get(cue).File)
+		if err != nil || pcm == nil {
+			if err != nil && a.err == nil {
+				a.err = err
+			}
+			pcm = defaultCueTones[cue].synth()
+		}
+		a.backend.load(cue, pcm)
+	}
+}
+
+func (a *audioSystem) play(cue soundCue) {
+	if volume, ok := a.volume(cue); ok {
+		a.backend.play(cue, volume)
+	}
+}
+
+// Muted cues are not played at all
+func (a *audioSystem) volume(cue soundCue) (float64, bool) {
+	c := userSettings.Cues.get(cue)
+	if c.Muted {
+		return 0, false
+	}
+	return float64(c.Volume) / 100, true
+}
+
+func (a *audioSystem) OnSignal(s Signal) {
+	switch s.Kind {
+	case todoTaskWorkEnded:
+		a.play(cueWorkEnd)
+	case todoTaskRestEnded:
+		a.play(cueRestEnd)
+	case todoTaskCompleted:
+		a.play(cueTaskDone)
+	case todoTaskWarning:
+		a.play(cueWarning)
+	case todoSettingsChanged:
+		a.reload()
+	}
+}
+
+// Decodes a user supplied file, the format is picked from the extension.
+// No path means the default cue should be used
+func loadCueFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var stream io.Reader
+	src := bytes.NewReader(data)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		stream, err = wav.DecodeWithSampleRate(audioSampleRate, src)
+	case ".ogg":
+		stream, err = vorbis.DecodeWithSampleRate(audioSampleRate, src)
+	case ".mp3":
+		stream, err = mp3.DecodeWithSampleRate(audioSampleRate, src)
+	default:
+		err = errUnknownAudioFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(stream)
+}
+
+func (c cueTone) synth() []byte {
+	const amplitude = 0.4
+	// Short fades to avoid clicks between the notes
+	const fadeTime = 0.01
+
+	noteFrames := int(c.noteTime * audioSampleRate)
+	fadeFrames := int(fadeTime * audioSampleRate)
+	pcm := make([]byte, 0, noteFrames*len(c.notes)*audioBytesPerFrame)
+	for _, freq := range c.notes {
+		for i := 0; i < noteFrames; i += 1 {
+			env := 1.0
+			if i < fadeFrames {
+				env = float64(i) / float64(fadeFrames)
+			} else if i > noteFrames-fadeFrames {
+				env = float64(noteFrames-i) / float64(fadeFrames)
+			}
+			v := math.Sin(2*math.Pi*freq*float64(i)/audioSampleRate) * amplitude * env
+			pcm = appendSample(pcm, v)
+		}
+	}
+	return pcm
+}
+
+// Appends the same value on both channels
+func appendSample(pcm []byte, v float64) []byte {
+	s := int16(math.Max(-1, math.Min(v, 1)) * math.MaxInt16)
+	lo, hi := byte(s), byte(s>>8)
+	return append(pcm, lo, hi, lo, hi)
+}
+
+func (e *ebitenAudio) load(cue soundCue, pcm []byte) {
+	if e.players[cue] != nil {
+		e.players[cue].Close()
+	}
+	e.players[cue] = audio.NewPlayerFromBytes(audioContext, pcm)
+}
+
+func (e *ebitenAudio) play(cue soundCue, volume float64) {
+	p := e.players[cue]
+	if p == nil {
+		return
+	}
+	p.SetVolume(volume)
+	p.Rewind()
+	p.Play()
+}
+
+func (n *nullAudio) load(cue soundCue, pcm []byte) {}
+
+func (n *nullAudio) play(cue soundCue, volume float64) {
+	n.plays += 1
+	n.last = cue
+	n.volume = volume
+}
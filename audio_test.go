@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestSignalCues(t *testing.T) {
+	saved := userSettings
+	defer func() { userSettings = saved }()
+
+	userSettings = defaultSettings()
+	userSettings.Sound = false
+	userSettings.Cues.WorkEnd.Volume = 40
+	userSettings.Cues.RestEnd.Volume = 100
+	userSettings.Cues.TaskDone.Volume = 0
+	userSettings.Cues.Warning.Volume = 80
+	userSettings.Cues.Warning.Muted = true
+
+	var a audioSystem
+	a.reload()
+	null, ok := a.backend.(*nullAudio)
+	if !ok {
+		t.Fatalf("backend = %T with the sound off, want *nullAudio", a.backend)
+	}
+	if a.err != nil {
+		t.Fatalf("default cues failed to load: %v", a.err)
+	}
+
+	tk := &task{name: "Write"}
+	cases := []struct {
+		kind   SignalKind
+		cue    soundCue
+		volume float64
+		played bool
+	}{
+		{todoTaskWorkEnded, cueWorkEnd, 0.4, true},
+		{todoTaskRestEnded, cueRestEnd, 1, true},
+		{todoTaskCompleted, cueTaskDone, 0, true},
+		{todoTaskWarning, cueWarning, 0, false},
+	}
+	for _, c := range cases {
+		plays := null.plays
+		a.OnSignal(Signal{Kind: c.kind, Value: tk})
+		if !c.played {
+			if null.plays != plays {
+				t.Errorf("signal %d: muted cue %d played", c.kind, null.last)
+			}
+			continue
+		}
+		if null.plays != plays+1 {
+			t.Errorf("signal %d: %d cues played, want 1", c.kind, null.plays-plays)
+			continue
+		}
+		if null.last != c.cue || null.volume != c.volume {
+			t.Errorf("signal %d played cue %d at %f, want cue %d at %f", c.kind, null.last, null.volume, c.cue, c.volume)
+		}
+	}
+}
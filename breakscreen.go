@@ -0,0 +1,128 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	breakSuggestionTicks = 8 * 60
+	// Skipping needs the button to be held that long
+	breakSkipHoldTicks = 90
+)
+
+// Full window modal shown while a task rests, so the
+// break actually happens. It goes away with the rest phase
+type breakScreen struct {
+	active bool
+	// Found again every frame, the tasks moving in their buffer
+	taskID int
+
+	suggestion int
+	ticks      int
+	holdTicks  int
+
+	titleRect      rectangle
+	timeRect       rectangle
+	suggestionRect rectangle
+	skipRect       rectangle
+
+	font *Font
+}
+
+func (b *breakScreen) init(font *Font) {
+	AddSignalListener(todoTaskRestStarted, b)
+	b.font = font
+}
+
+func (b *breakScreen) layout() {
+	const breakScreenPadding = 20
+
+	layout := newRectLayout(screenBounds)
+	layout.cut(rectCutUp, 120, 0)
+	b.titleRect = layout.cut(rectCutUp, largeTextSize, breakScreenPadding).full
+	b.timeRect = layout.cut(rectCutUp, focusTextSize, breakScreenPadding*2).full
+	b.suggestionRect = layout.cut(rectCutUp, textSize, breakScreenPadding*3).full
+	skipRect := layout.cut(rectCutUp, btnHeight, 0)
+	skipRect.keepCenter(200)
+	b.skipRect = skipRect.remaining
+}
+
+func (b *breakScreen) update(mPos point, tasks *taskBuffer) {
+	if !b.active {
+		return
+	}
+	t := tasks.find(b.taskID)
+	if t == nil || t.state != taskStateRest && t.state != taskStatePaused {
+		b.close()
+		return
+	}
+
+	b.ticks += 1
+	if b.ticks >= breakSuggestionTicks {
+		b.ticks = 0
+		b.suggestion += 1
+	}
+
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && b.skipRect.boundCheck(mPos) {
+		b.holdTicks += 1
+		if b.holdTicks >= breakSkipHoldTicks {
+			t.skipBreak()
+			b.close()
+		}
+	} else {
+		b.holdTicks = 0
+	}
+}
+
+func (b *breakScreen) close() {
+	b.active = false
+	FireSignal(todoBreakScreenClosed, SignalNoArgs)
+}
+
+func (b *breakScreen) draw(dst *ebiten.Image, tasks *taskBuffer) {
+	t := tasks.find(b.taskID)
+	if !b.active || t == nil {
+		return
+	}
+	drawRect(dst, screenBounds, theme.Background3)
+
+	drawTextCenter(dst, textOptions{
+		font: b.font, text: tr("Time for a break"), bounds: b.titleRect,
+		size: largeTextSize, clr: theme.Text,
+	})
+	drawTextCenter(dst, textOptions{
+		font: b.font, text: t.getRestTime(), bounds: b.timeRect,
+		size: focusTextSize, clr: theme.Rest,
+	})
+	// The default suggestions are translated, the user ones
+	// not being in the catalogs stay as they are
+	if suggestions := userSettings.BreakSuggestions; len(suggestions) > 0 {
+		drawTextCenter(dst, textOptions{
+			font: b.font, text: tr(suggestions[b.suggestion%len(suggestions)]), bounds: b.suggestionRect,
+			size: textSize, clr: theme.MutedText,
+		})
+	}
+
+	hold := b.skipRect
+	hold.width *= float64(b.holdTicks) / breakSkipHoldTicks
+	drawRect(dst, hold, theme.Progress)
+	drawTextBtn(dst, b.skipRect, tr("Hold to skip"), textSize)
+}
+
+func (b *breakScreen) OnSignal(s Signal) {
+	switch s.Kind {
+	case todoTaskRestStarted:
+		if !userSettings.BreakScreen {
+			return
+		}
+		t := s.Value.(*task)
+		if !b.active {
+			FireSignal(todoBreakScreenOpened, SignalNoArgs)
+		}
+		b.active = true
+		b.taskID = t.id
+		b.ticks = 0
+		b.holdTicks = 0
+		b.suggestion = t.breaksTaken
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"todo/ui"
+)
+
+const maxDialogButtons = 4
+
+// Modal window asking the user to pick one of the buttons.
+// The choice is sent back through the signal given when opened
+type dialogWindow struct {
+	active   bool
+	position point
+
+	rect        rectLayout
+	titleRect   rectLayout
+	messageRect rectLayout
+	btnRect     rectLayout
+
+	choiceKind SignalKind
+
+	widgets ui.Root
+	title   ui.Label
+	message ui.Label
+
+	font          *Font
+	rectOutline   *ebiten.Image
+	outlineConstr constraint
+}
+
+func (d *dialogWindow) init(font *Font, outline *ebiten.Image) {
+	const dialogWindowPadding = 10
+
+	d.rect = newRectLayout(rectangle{
+		x:      0,
+		y:      0,
+		width:  420,
+		height: 170,
+	})
+	d.rect.cut(rectCutUp, dialogWindowPadding, 0)
+	d.rect.cut(rectCutDown, dialogWindowPadding, 0)
+	d.rect.cut(rectCutLeft, dialogWindowPadding*2, 0)
+	d.rect.cut(rectCutRight, dialogWindowPadding*2, 0)
+
+	d.titleRect = d.rect.cut(rectCutUp, textSize, 15)
+	d.btnRect = d.rect.cut(rectCutDown, btnHeight, dialogWindowPadding)
+	d.messageRect = d.rect.cut(rectCutUp, d.rect.remaining.height, 0)
+
+	d.title.SetBounds(uiRect(rectangle{d.titleRect.x(), d.titleRect.y(), d.titleRect.width(), textSize}))
+	d.message.Size = ui.SizeSmall
+	d.message.SetBounds(uiRect(rectangle{d.messageRect.x(), d.messageRect.y(), d.messageRect.width(), smallTextSize}))
+
+	d.font = font
+	d.rectOutline = outline
+	d.outlineConstr = constraint{2, 2, 2, 2}
+}
+
+// Keeps the dialog centered when the app is resized
+func (d *dialogWindow) layout() {
+	d.position = point{
+		screenBounds.width/2 - d.rect.full.width/2,
+		screenBounds.height/2 - d.rect.full.height/2,
+	}
+}
+
+func (d *dialogWindow) open(title, message string, buttons []string, choiceKind SignalKind) {
+	const btnSpacing = 10
+
+	d.title.Text = title
+	d.message.Text = message
+	if len(buttons) > maxDialogButtons {
+		buttons = buttons[:maxDialogButtons]
+	}
+	d.choiceKind = choiceKind
+
+	d.widgets.Clear()
+	d.widgets.Add(&d.title)
+	d.widgets.Add(&d.message)
+	layout := newRectLayout(d.btnRect.remaining)
+	count := float64(len(buttons))
+	btnWidth := (layout.remaining.width - btnSpacing*(count-1)) / count
+	for i, text := range buttons {
+		choice := i
+		btn := &ui.Button{Text: text, OnClick: func() { d.choose(choice) }}
+		btn.SetBounds(uiRect(layout.cut(rectCutLeft, btnWidth, btnSpacing).full))
+		d.widgets.Add(btn)
+	}
+
+	d.active = true
+	FireSignal(todoDialogOpened, SignalNoArgs)
+}
+
+func (d *dialogWindow) update(mPos point, mLeft bool) {
+	if d.active {
+		d.widgets.Update(newUIInput(mPos, mLeft, d.position, d.font))
+	}
+}
+
+func (d *dialogWindow) draw(dst *ebiten.Image) {
+	if d.active {
+		drawModalBackground(dst)
+
+		rect := d.rect.full.addPoint(d.position)
+		drawRect(dst, rect, theme.Background1)
+		drawImageSlice(dst, rect, d.rectOutline, d.outlineConstr, theme.Text)
+		d.widgets.Draw(widgetPainter{dst: dst, font: d.font, origin: d.position})
+	}
+}
+
+func (d *dialogWindow) choose(choice int) {
+	d.active = false
+	FireSignal(todoDialogClosed, SignalNoArgs)
+	FireSignal(d.choiceKind, SignalInt(choice))
+}
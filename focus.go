@@ -0,0 +1,206 @@
+package main
+
+import (
+	"todo/anim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const focusTextSize = 96
+
+// Hides everything but the selected task, its timer filling
+// the window. Leaves on its own once the task stops running.
+// The task is found again by id, it may have moved or be gone
+type focusView struct {
+	active  bool
+	leaving bool
+	taskID  int
+	keys    []ebiten.Key
+
+	// The view grows out of the main window
+	expand    float64
+	collapse  float64
+	enterAnim anim.Animation
+	leaveAnim anim.Animation
+	fromRect  rectangle
+	toRect    rectangle
+
+	nameRect     rectangle
+	notesRect    rectangle
+	timerRect    rectangle
+	progressRect rectangle
+	sessionsRect rectangle
+	hintRect     rectangle
+}
+
+func (f *focusView) init() {
+	f.enterAnim = anim.NewAnimation("enter", f)
+	f.enterAnim.AddProperty("expand", &f.expand, 0, false)
+	f.enterAnim.AddKey("expand", anim.AnimationKey{
+		Easing:   anim.EaseOutCubic,
+		Duration: anim.SecondsToTicks(0.3),
+		Change:   1,
+	})
+	f.leaveAnim = anim.NewAnimation("leave", f)
+	f.leaveAnim.AddProperty("collapse", &f.collapse, 0, false)
+	f.leaveAnim.AddKey("collapse", anim.AnimationKey{
+		Easing:   anim.EaseInCubic,
+		Duration: anim.SecondsToTicks(0.2),
+		Change:   1,
+	})
+}
+
+func (f *focusView) layout(from rectangle) {
+	const focusPadding = 20
+
+	f.fromRect = from
+	f.toRect = screenBounds
+	layout := newRectLayout(f.toRect)
+	layout.cut(rectCutUp, focusPadding*2, 0)
+	layout.cut(rectCutDown, focusPadding, 0)
+	layout.cut(rectCutLeft, focusPadding*4, 0)
+	layout.cut(rectCutRight, focusPadding*4, 0)
+	f.nameRect = layout.cut(rectCutUp, largeTextSize, focusPadding).full
+	f.notesRect = layout.cut(rectCutUp, textSize, focusPadding).full
+	f.hintRect = layout.cut(rectCutDown, smallTextSize, focusPadding).full
+	f.sessionsRect = layout.cut(rectCutDown, 20, focusPadding).full
+	f.progressRect = layout.cut(rectCutDown, 10, focusPadding).full
+	f.timerRect = layout.remaining
+}
+
+func (f *focusView) enter(t *task) {
+	f.active = true
+	f.leaving = false
+	f.taskID = t.id
+	f.expand = 0
+	f.collapse = 0
+	f.leaveAnim.Playing = false
+	f.enterAnim.Play()
+}
+
+func (f *focusView) leave() {
+	if !f.active || f.leaving {
+		return
+	}
+	f.leaving = true
+	f.leaveAnim.Play()
+}
+
+func (f *focusView) update(tasks *taskBuffer) {
+	f.enterAnim.Update()
+	f.leaveAnim.Update()
+	if !f.active || f.leaving {
+		return
+	}
+
+	t := tasks.find(f.taskID)
+	if t == nil || t.state == taskStateIdle || t.state == taskStatePaused {
+		f.leave()
+		return
+	}
+	f.keys = inpututil.AppendPressedKeys(f.keys[:0])
+	for _, k := range f.keys {
+		if inpututil.IsKeyJustPressed(k) {
+			f.leave()
+			return
+		}
+	}
+}
+
+func (f *focusView) level() float64 {
+	return f.expand * (1 - f.collapse)
+}
+
+func (f *focusView) draw(dst *ebiten.Image, tasks *taskBuffer) {
+	if !f.active {
+		return
+	}
+	level := f.level()
+	lerp := func(a, b float64) float64 {
+		return a + (b-a)*level
+	}
+	drawRect(dst, rectangle{
+		lerp(f.fromRect.x, f.toRect.x), lerp(f.fromRect.y, f.toRect.y),
+		lerp(f.fromRect.width, f.toRect.width), lerp(f.fromRect.height, f.toRect.height),
+	}, theme.Background2)
+
+	t := tasks.find(f.taskID)
+	if t == nil {
+		return
+	}
+	drawTextCenter(dst, textOptions{
+		font: &defaultFont, text: t.name, bounds: f.nameRect,
+		size: largeTextSize, clr: fadeColor(theme.Text, level),
+	})
+	notes := t.notes
+	if notes == "" && len(t.phases) > 0 {
+		notes = tr(t.currentPhase().name)
+	}
+	drawTextCenter(dst, textOptions{
+		font: &defaultFont, text: notes, bounds: f.notesRect,
+		size: textSize, clr: fadeColor(theme.MutedText, level),
+	})
+
+	timeText := t.getCurrentTime()
+	timeClr := theme.Text
+	if t.overtime {
+		timeClr = theme.Flow
+	}
+	switch {
+	case userSettings.TimerStyle == timerStylePie && t.kind != taskKindStopwatch:
+		remaining, pieClr := 1-t.progress(), rampColor(t, *t.currentPhase().clr)
+		if t.overtime {
+			remaining, pieClr = 1, theme.Flow
+		}
+		digits := ""
+		if userSettings.TimerDigits {
+			digits = timeText
+		}
+		drawTimerPie(dst, f.timerRect, remaining, fadeColor(pieClr, level), fadeColor(theme.Background2, level), digits, largeTextSize)
+	default:
+		drawTextCenter(dst, textOptions{
+			font: &defaultFont, text: timeText, bounds: f.timerRect,
+			size: focusTextSize, clr: fadeColor(timeClr, level),
+		})
+	}
+
+	// Progress of the current phase then of the whole task
+	drawRect(dst, f.progressRect, fadeColor(theme.Background3, level))
+	progress := f.progressRect
+	progress.width *= t.progress()
+	drawRect(dst, progress, fadeColor(rampColor(t, theme.Text), level))
+	if t.kind != taskKindStopwatch && t.sessionRequired > 0 {
+		const sessionSpacing = 4
+		count := float64(t.sessionRequired)
+		width := (f.sessionsRect.width - sessionSpacing*(count-1)) / count
+		for i := 0; i < t.sessionRequired; i += 1 {
+			clr := theme.Background3
+			if i < t.sessionCompleted {
+				clr = theme.Text
+			}
+			drawRect(dst, rectangle{
+				f.sessionsRect.x + float64(i)*(width+sessionSpacing), f.sessionsRect.y,
+				width, f.sessionsRect.height,
+			}, fadeColor(clr, level))
+		}
+	}
+
+	drawTextCenter(dst, textOptions{
+		font: &defaultFont, text: tr("Press any key to leave the focus mode"), bounds: f.hintRect,
+		size: smallTextSize, clr: fadeColor(theme.MutedText, level),
+	})
+}
+
+func (f *focusView) OnAnimationEnd(name string) {
+	switch name {
+	case "leave":
+		f.active = false
+		f.leaving = false
+	}
+}
+
+func fadeColor(c Color, alpha float64) Color {
+	c[3] = uint8(float64(c[3]) * alpha)
+	return c
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+
+	"todo/shaping"
+)
+
+// Tried first for the glyphs missing from the main font,
+// before the ones found on the system
+var fallbackAssetNames = []string{
+	"NotoSansSymbols-Regular.ttf",
+}
+
+// Only loaded the first time a glyph is missing
+var (
+	fallbackFonts  []*opentype.Font
+	fallbackLoaded bool
+)
+
+// Tried in order after the bundled ones
+func systemFallbackPaths() []string {
+	switch runtime.GOOS {
+	case "windows":
+		dir := filepath.Join(os.Getenv("WINDIR"), "Fonts")
+		return []string{
+			filepath.Join(dir, "seguisym.ttf"),
+			filepath.Join(dir, "seguiemj.ttf"),
+			filepath.Join(dir, "msyh.ttc"),
+			filepath.Join(dir, "YuGothR.ttc"),
+			filepath.Join(dir, "malgun.ttf"),
+			filepath.Join(dir, "arialuni.ttf"),
+		}
+	case "darwin":
+		return []string{
+			"/System/Library/Fonts/Apple Symbols.ttf",
+			"/System/Library/Fonts/PingFang.ttc",
+			"/System/Library/Fonts/Hiragino Sans GB.ttc",
+			"/System/Library/Fonts/AppleSDGothicNeo.ttc",
+			"/Library/Fonts/Arial Unicode.ttf",
+		}
+	default:
+		return []string{
+			"/usr/share/fonts/truetype/noto/NotoSansSymbols2-Regular.ttf",
+			"/usr/share/fonts/truetype/noto/NotoEmoji-Regular.ttf",
+			"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+			"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+			"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+			"/usr/share/fonts/TTF/DejaVuSans.ttf",
+		}
+	}
+}
+
+// Loaded again the next time a glyph is missing
+func resetFallbackFonts() {
+	fallbackFonts = nil
+	fallbackLoaded = false
+}
+
+// The fonts that can't be found or parsed are skipped,
+// they are only nice to have
+func loadFallbackFonts() []*opentype.Font {
+	if fallbackLoaded {
+		return fallbackFonts
+	}
+	fallbackLoaded = true
+	for _, name := range fallbackAssetNames {
+		if tt := assets.fallbackFont(name); tt != nil {
+			fallbackFonts = append(fallbackFonts, tt)
+		}
+	}
+	for _, path := range systemFallbackPaths() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if tt, err := parseFont(data); err == nil {
+			fallbackFonts = append(fallbackFonts, tt)
+		}
+	}
+	return fallbackFonts
+}
+
+// Collections only give their first font
+func parseFont(data []byte) (*opentype.Font, error) {
+	c, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Font(0)
+}
+
+func hasGlyph(tt *opentype.Font, buf *sfnt.Buffer, r rune) bool {
+	i, err := tt.GlyphIndex(buf, r)
+	return err == nil && i != 0
+}
+
+// The main face unless one of the fallbacks has the glyph,
+// the faces of the fallbacks being made on demand
+func (f *Font) faceFor(r rune, size int) font.Face {
+	main := f.faces[size]
+	if r < 0x80 || hasGlyph(assets.font(f.name), &f.buf, r) {
+		return main
+	}
+	fallbacks := loadFallbackFonts()
+	for i, tt := range fallbacks {
+		if !hasGlyph(tt, &f.buf, r) {
+			continue
+		}
+		faces := f.fallbackFaces[size]
+		if len(faces) < len(fallbacks) {
+			faces = make([]font.Face, len(fallbacks))
+			f.fallbackFaces[size] = faces
+		}
+		if faces[i] == nil {
+			face, err := opentype.NewFace(tt, &opentype.FaceOptions{
+				Size:    float64(size),
+				DPI:     72 * f.scale,
+				Hinting: font.HintingNone,
+			})
+			if err != nil {
+				return main
+			}
+			faces[i] = face
+		}
+		return faces[i]
+	}
+	return main
+}
+
+type textRun struct {
+	face font.Face
+	text string
+	// From the start of the text, in pixels
+	x fixed.Int26_6
+}
+
+// The text in visual order with the Arabic letters joined,
+// split where the face changes
+func (f *Font) runs(t string, size float64) []textRun {
+	return f.faceRuns(string(shaping.Shape(t).Visual), size)
+}
+
+// Splits the text where the face changes, so the
+// drawing and the measures go glyph by glyph
+func (f *Font) faceRuns(t string, size float64) []textRun {
+	var runs []textRun
+	x := fixed.Int26_6(0)
+	start := 0
+	var current font.Face
+	for i, r := range t {
+		face := f.faceFor(r, int(size))
+		if face != current && i > start {
+			runs = append(runs, textRun{current, t[start:i], x})
+			x += font.MeasureString(current, t[start:i])
+			start = i
+		}
+		current = face
+	}
+	if start < len(t) {
+		runs = append(runs, textRun{current, t[start:], x})
+	}
+	return runs
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// The oldest changes are forgotten past this
+const maxHistory = 50
+
+type (
+	// A change to the tasks that can be taken back. The tasks are
+	// found again by id, the pointers to them not surviving a change
+	command interface {
+		do(t *Todo)
+		undo(t *Todo)
+		// Shown when it is undone or done again
+		describe() string
+	}
+
+	// Undone changes can be done again until something new is done
+	history struct {
+		done   []command
+		undone []command
+	}
+
+	addTaskCommand struct {
+		task task
+	}
+
+	// Undoing it restores the task at its place in the list
+	archiveTaskCommand struct {
+		task task
+		at   int
+	}
+
+	// Gone for good once it falls out of the history
+	deleteTaskCommand struct {
+		task task
+		at   int
+	}
+
+	// Back at the end of the list, undoing it puts
+	// the task back at its place in the archive
+	restoreTaskCommand struct {
+		task       task
+		at         int
+		archivedAt time.Time
+	}
+
+	moveTaskCommand struct {
+		name     string
+		from, to int
+	}
+
+	editNotesCommand struct {
+		id     int
+		name   string
+		before string
+		after  string
+	}
+
+	sessionCountCommand struct {
+		id            int
+		name          string
+		before, after int
+	}
+)
+
+// Does it and keeps it
+func (h *history) run(t *Todo, c command) {
+	c.do(t)
+	h.push(c)
+}
+
+// Keeps a change that was already done
+func (h *history) push(c command) {
+	if len(h.done) == maxHistory {
+		copy(h.done, h.done[1:])
+		h.done = h.done[:maxHistory-1]
+	}
+	h.done = append(h.done, c)
+	h.undone = h.undone[:0]
+}
+
+func (h *history) last() command {
+	if len(h.done) == 0 {
+		return nil
+	}
+	return h.done[len(h.done)-1]
+}
+
+func (h *history) undo(t *Todo) command {
+	c := h.last()
+	if c == nil {
+		return nil
+	}
+	h.done = h.done[:len(h.done)-1]
+	c.undo(t)
+	h.undone = append(h.undone, c)
+	return c
+}
+
+func (h *history) redo(t *Todo) command {
+	if len(h.undone) == 0 {
+		return nil
+	}
+	c := h.undone[len(h.undone)-1]
+	h.undone = h.undone[:len(h.undone)-1]
+	c.do(t)
+	h.done = append(h.done, c)
+	return c
+}
+
+func (c *addTaskCommand) do(t *Todo) {
+	t.tasks.addTask(c.task)
+	t.list.addItem()
+}
+
+func (c *addTaskCommand) undo(t *Todo) {
+	// As it is now, for doing it again
+	c.task = t.tasks.copyTask(c.task.id)
+	if at := t.tasks.removeTask(c.task.id); at > -1 {
+		t.list.removeItem(at)
+	}
+}
+
+func (c *addTaskCommand) describe() string {
+	return tr("Add {task}", "{task}", c.task.name)
+}
+
+func (c *archiveTaskCommand) do(t *Todo) {
+	c.task = t.tasks.copyTask(c.task.id)
+	c.task.archivedAt = time.Now()
+	if c.at = t.tasks.removeTask(c.task.id); c.at > -1 {
+		t.list.removeItem(c.at)
+		t.archive.addTask(c.task)
+		t.archiveWindow.addItem()
+	}
+}
+
+func (c *archiveTaskCommand) undo(t *Todo) {
+	c.task = t.archive.copyTask(c.task.id)
+	if t.archive.removeTask(c.task.id) == -1 {
+		return
+	}
+	t.archiveWindow.removeItem()
+	c.task.archivedAt = time.Time{}
+	t.tasks.insertTask(c.at, c.task)
+	t.list.insertItem(c.at)
+}
+
+func (c *archiveTaskCommand) describe() string {
+	return tr("Archive {task}", "{task}", c.task.name)
+}
+
+func (c *deleteTaskCommand) do(t *Todo) {
+	c.task = t.tasks.copyTask(c.task.id)
+	if c.at = t.tasks.removeTask(c.task.id); c.at > -1 {
+		t.list.removeItem(c.at)
+	}
+}
+
+func (c *deleteTaskCommand) undo(t *Todo) {
+	t.tasks.insertTask(c.at, c.task)
+	t.list.insertItem(c.at)
+}
+
+func (c *deleteTaskCommand) describe() string {
+	return tr("Delete {task}", "{task}", c.task.name)
+}
+
+func (c *restoreTaskCommand) do(t *Todo) {
+	c.task = t.archive.copyTask(c.task.id)
+	if c.at = t.archive.removeTask(c.task.id); c.at > -1 {
+		t.archiveWindow.removeItem()
+		c.archivedAt = c.task.archivedAt
+		c.task.archivedAt = time.Time{}
+		t.tasks.addTask(c.task)
+		t.list.addItem()
+	}
+}
+
+func (c *restoreTaskCommand) undo(t *Todo) {
+	c.task = t.tasks.copyTask(c.task.id)
+	at := t.tasks.removeTask(c.task.id)
+	if at == -1 {
+		return
+	}
+	t.list.removeItem(at)
+	c.task.archivedAt = c.archivedAt
+	t.archive.insertTask(c.at, c.task)
+	t.archiveWindow.addItem()
+}
+
+func (c *restoreTaskCommand) describe() string {
+	return tr("Restore {task}", "{task}", c.task.name)
+}
+
+func (c *moveTaskCommand) do(t *Todo) {
+	t.tasks.moveTask(c.from, c.to)
+}
+
+func (c *moveTaskCommand) undo(t *Todo) {
+	t.tasks.moveTask(c.to, c.from)
+}
+
+func (c *moveTaskCommand) describe() string {
+	return tr("Move {task}", "{task}", c.name)
+}
+
+func (c *editNotesCommand) do(t *Todo) {
+	if task := t.findTask(c.id); task != nil {
+		task.notes = c.after
+	}
+}
+
+func (c *editNotesCommand) undo(t *Todo) {
+	if task := t.findTask(c.id); task != nil {
+		task.notes = c.before
+	}
+}
+
+func (c *editNotesCommand) describe() string {
+	return tr("Notes of {task}", "{task}", c.name)
+}
+
+func (c *editNotesCommand) ToString() string {
+	return c.describe()
+}
+
+func (c *sessionCountCommand) do(t *Todo) {
+	c.set(t, c.after)
+}
+
+func (c *sessionCountCommand) undo(t *Todo) {
+	c.set(t, c.before)
+}
+
+// Changing the count can complete the task, or make it not done
+func (c *sessionCountCommand) set(t *Todo, count int) {
+	if task := t.findTask(c.id); task != nil {
+		task.sessionRequired = count
+		task.done = task.sessionCompleted >= count
+	}
+}
+
+func (c *sessionCountCommand) describe() string {
+	return tr("Sessions of {task}", "{task}", c.name)
+}
+
+// In the list first, then in the archive
+func (t *Todo) findTask(id int) *task {
+	for _, buffer := range []*taskBuffer{&t.tasks, &t.archive} {
+		for i := 0; i < buffer.count; i += 1 {
+			if buffer.items[i].id == id {
+				return &buffer.items[i]
+			}
+		}
+	}
+	return nil
+}
+
+// The selection follows its task, which may have moved or be gone
+func (t *Todo) changeTasks(change func()) {
+	id := -1
+	if t.selected != nil {
+		id = t.selected.id
+	}
+	change()
+	t.selected = nil
+	t.list.selected = nil
+	for i := 0; i < t.tasks.count; i += 1 {
+		if t.tasks.items[i].id == id {
+			t.selected = &t.tasks.items[i]
+			t.list.selected = &t.list.items[i]
+		}
+	}
+}
+
+func (t *Todo) undo() {
+	var c command
+	t.changeTasks(func() { c = t.history.undo(t) })
+	if c != nil {
+		t.toasts.push(tr("Undone: {action}", "{action}", c.describe()))
+	}
+}
+
+func (t *Todo) redo() {
+	var c command
+	t.changeTasks(func() { c = t.history.redo(t) })
+	if c != nil {
+		t.toasts.push(tr("Redone: {action}", "{action}", c.describe()))
+	}
+}
+
+// Ctrl+Z and Ctrl+Shift+Z (Cmd on macOS), and Alt+Up and
+// Alt+Down moving the selected task, only when nothing
+// else is open or being typed in
+func (t *Todo) updateHistory() {
+	if t.windowOpen || t.focus.active || t.mainWindow.notesSelected {
+		return
+	}
+	if t.selected != nil && ebiten.IsKeyPressed(ebiten.KeyAlt) {
+		switch {
+		case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+			t.moveSelected(-1)
+		case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+			t.moveSelected(1)
+		}
+	}
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)
+	if !ctrl || !inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		return
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		t.redo()
+	} else {
+		t.undo()
+	}
+}
+
+// Archiving and deleting being the ones that can't be seen
+// back, the toast saying so can take it back as long as
+// nothing else was done since
+func (t *Todo) pushUndo(text string, c command) {
+	t.toasts.pushAction(text, tr("Undo"), func() {
+		if t.history.last() == c {
+			t.undo()
+		}
+	})
+}
+
+func (t *Todo) archiveSelected() {
+	c := &archiveTaskCommand{task: task{id: t.selected.id}}
+	t.changeTasks(func() { t.history.run(t, c) })
+	t.pushUndo(tr("Archived {task}", "{task}", c.task.name), c)
+}
+
+func (t *Todo) deleteSelected() {
+	c := &deleteTaskCommand{task: task{id: t.selected.id}}
+	t.changeTasks(func() { t.history.run(t, c) })
+	t.pushUndo(tr("Deleted {task}", "{task}", c.task.name), c)
+}
+
+// By its index in the archive
+func (t *Todo) restoreArchived(index int) {
+	if index < 0 || index >= t.archive.count {
+		return
+	}
+	c := &restoreTaskCommand{task: task{id: t.archive.getTask(index).id}}
+	t.changeTasks(func() { t.history.run(t, c) })
+	t.toasts.push(tr("Restored {task}", "{task}", c.task.name))
+}
+
+// Up or down the list by one, staying in it
+func (t *Todo) moveSelected(by int) {
+	from := t.tasks.indexOf(t.selected.id)
+	to := from + by
+	if from == -1 || to < 0 || to >= t.tasks.count {
+		return
+	}
+	c := &moveTaskCommand{name: t.selected.name, from: from, to: to}
+	t.changeTasks(func() { t.history.run(t, c) })
+}
+
+// Not below the sessions already done, nor for the stopwatches
+func (t *Todo) changeSessions(by int) {
+	task := t.selected
+	if task == nil || task.kind == taskKindStopwatch {
+		return
+	}
+	after := task.sessionRequired + by
+	if after < minSessionCount || after < task.sessionCompleted || after > maxSessionCount {
+		return
+	}
+	t.history.run(t, &sessionCountCommand{
+		id: task.id, name: task.name,
+		before: task.sessionRequired, after: after,
+	})
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The messages in english, as they are written in the code
+func useDefaultLocale() {
+	loadLocales()
+	selectLanguage(defaultLocale)
+}
+
+// Only what the commands touch, without the windows being drawn
+func newTestTodo(names ...string) *Todo {
+	useDefaultLocale()
+	t := new(Todo)
+	todo = t
+	t.signals.init()
+	t.tasks = newTaskBuffer()
+	t.archive = newTaskBuffer()
+	t.font = NewFont(defaultFontName, 72, []int{smallTextSize, textSize, largeTextSize})
+	t.list.init(&t.font, nil)
+	t.list.layout(rectangle{0, 0, 200, 600})
+	t.archiveWindow.init(&t.font, nil)
+	for _, name := range names {
+		t.tasks.addTask(task{id: t.genID(), name: name, sessionRequired: 3})
+		t.list.addItem()
+	}
+	return t
+}
+
+func (t *Todo) selectTask(index int) {
+	t.selected = t.tasks.getTask(index)
+	t.list.selected = &t.list.items[index]
+}
+
+func taskNames(buffer *taskBuffer) []string {
+	names := []string{}
+	for i := 0; i < buffer.count; i += 1 {
+		names = append(names, buffer.items[i].name)
+	}
+	return names
+}
+
+// The rows and the tasks going together
+func checkTasks(test *testing.T, t *Todo, tasks, archive []string) {
+	test.Helper()
+	if got := taskNames(&t.tasks); !reflect.DeepEqual(got, tasks) {
+		test.Errorf("tasks = %v, want %v", got, tasks)
+	}
+	if got := taskNames(&t.archive); !reflect.DeepEqual(got, archive) {
+		test.Errorf("archive = %v, want %v", got, archive)
+	}
+	if t.list.count != t.tasks.count {
+		test.Errorf("list has %d rows for %d tasks", t.list.count, t.tasks.count)
+	}
+	if t.archiveWindow.count != t.archive.count {
+		test.Errorf("archive window has %d rows for %d tasks", t.archiveWindow.count, t.archive.count)
+	}
+}
+
+func TestAddTaskCommand(test *testing.T) {
+	t := newTestTodo("a")
+	t.addTask(task{name: "b", sessionRequired: 1})
+	checkTasks(test, t, []string{"a", "b"}, []string{})
+	t.undo()
+	checkTasks(test, t, []string{"a"}, []string{})
+	t.redo()
+	checkTasks(test, t, []string{"a", "b"}, []string{})
+}
+
+func TestArchiveTaskCommand(test *testing.T) {
+	t := newTestTodo("a", "b", "c")
+	t.selectTask(1)
+	t.archiveSelected()
+	checkTasks(test, t, []string{"a", "c"}, []string{"b"})
+	if t.archive.items[0].archivedAt.IsZero() {
+		test.Error("archived task without a date")
+	}
+	t.undo()
+	checkTasks(test, t, []string{"a", "b", "c"}, []string{})
+	if !t.tasks.items[1].archivedAt.IsZero() {
+		test.Error("unarchived task kept its date")
+	}
+	t.redo()
+	checkTasks(test, t, []string{"a", "c"}, []string{"b"})
+}
+
+func TestDeleteTaskCommand(test *testing.T) {
+	t := newTestTodo("a", "b", "c")
+	t.selectTask(0)
+	t.deleteSelected()
+	checkTasks(test, t, []string{"b", "c"}, []string{})
+	t.undo()
+	checkTasks(test, t, []string{"a", "b", "c"}, []string{})
+	t.redo()
+	checkTasks(test, t, []string{"b", "c"}, []string{})
+}
+
+func TestRestoreTaskCommand(test *testing.T) {
+	t := newTestTodo("a", "b", "c")
+	for _, index := range []int{2, 0} {
+		t.selectTask(index)
+		t.archiveSelected()
+	}
+	checkTasks(test, t, []string{"b"}, []string{"c", "a"})
+
+	t.restoreArchived(0)
+	checkTasks(test, t, []string{"b", "c"}, []string{"a"})
+	if !t.tasks.items[1].archivedAt.IsZero() {
+		test.Error("restored task kept its date")
+	}
+	// Back at its place in the archive, with its date
+	t.undo()
+	checkTasks(test, t, []string{"b"}, []string{"c", "a"})
+	if t.archive.items[0].archivedAt.IsZero() {
+		test.Error("task back in the archive without a date")
+	}
+	t.redo()
+	checkTasks(test, t, []string{"b", "c"}, []string{"a"})
+
+	t.restoreArchived(5)
+	checkTasks(test, t, []string{"b", "c"}, []string{"a"})
+}
+
+func TestMoveTaskCommand(test *testing.T) {
+	t := newTestTodo("a", "b", "c")
+	t.selectTask(0)
+	t.moveSelected(1)
+	t.moveSelected(1)
+	checkTasks(test, t, []string{"b", "c", "a"}, []string{})
+	// Already the last one
+	t.moveSelected(1)
+	if len(t.history.done) != 2 {
+		test.Errorf("%d changes kept, want 2", len(t.history.done))
+	}
+	t.undo()
+	checkTasks(test, t, []string{"b", "a", "c"}, []string{})
+	t.undo()
+	checkTasks(test, t, []string{"a", "b", "c"}, []string{})
+	t.redo()
+	checkTasks(test, t, []string{"b", "a", "c"}, []string{})
+}
+
+func TestEditNotesCommand(test *testing.T) {
+	t := newTestTodo("a")
+	task := t.tasks.getTask(0)
+	task.notes = "after"
+	t.history.push(&editNotesCommand{id: task.id, name: task.name, before: "before", after: "after"})
+	t.undo()
+	if got := t.tasks.items[0].notes; got != "before" {
+		test.Errorf("notes = %q after undo, want %q", got, "before")
+	}
+	t.redo()
+	if got := t.tasks.items[0].notes; got != "after" {
+		test.Errorf("notes = %q after redo, want %q", got, "after")
+	}
+}
+
+func TestSessionCountCommand(test *testing.T) {
+	t := newTestTodo("a")
+	t.selectTask(0)
+	t.selected.sessionCompleted = 2
+
+	t.changeSessions(-1)
+	if s := t.tasks.items[0]; s.sessionRequired != 2 || !s.done {
+		test.Errorf("sessions = %d, done %t, want 2 and done", s.sessionRequired, s.done)
+	}
+	// Not below the ones already done
+	t.changeSessions(-1)
+	if got := t.tasks.items[0].sessionRequired; got != 2 {
+		test.Errorf("sessions = %d, want 2", got)
+	}
+
+	t.undo()
+	if s := t.tasks.items[0]; s.sessionRequired != 3 || s.done {
+		test.Errorf("sessions = %d, done %t after undo, want 3 and not done", s.sessionRequired, s.done)
+	}
+	// Done in the meantime, undoing works it out again
+	t.redo()
+	t.changeSessions(1)
+	t.tasks.items[0].sessionCompleted = 3
+	t.undo()
+	if s := t.tasks.items[0]; s.sessionRequired != 2 || !s.done {
+		test.Errorf("sessions = %d, done %t after undo, want 2 and done", s.sessionRequired, s.done)
+	}
+
+	t.tasks.items[0].kind = taskKindStopwatch
+	t.changeSessions(1)
+	if got := t.tasks.items[0].sessionRequired; got != 2 {
+		test.Errorf("stopwatch sessions = %d, want them left alone", got)
+	}
+}
+
+func TestHistoryLimit(test *testing.T) {
+	t := newTestTodo()
+	for i := 0; i < maxHistory+10; i += 1 {
+		t.addTask(task{name: "task", sessionRequired: 1})
+	}
+	if len(t.history.done) != maxHistory {
+		test.Fatalf("%d changes kept, want %d", len(t.history.done), maxHistory)
+	}
+	for i := 0; i < maxHistory+10; i += 1 {
+		t.undo()
+	}
+	// The oldest ones can't be undone anymore
+	if t.tasks.count != 10 {
+		test.Errorf("%d tasks left, want 10", t.tasks.count)
+	}
+	if t.list.count != t.tasks.count {
+		test.Errorf("list has %d rows for %d tasks", t.list.count, t.tasks.count)
+	}
+}
+
+func TestRedoClearedByNewChange(test *testing.T) {
+	t := newTestTodo("a", "b")
+	t.selectTask(0)
+	t.deleteSelected()
+	t.undo()
+	if len(t.history.undone) != 1 {
+		test.Fatalf("%d changes to redo, want 1", len(t.history.undone))
+	}
+	t.selectTask(1)
+	t.moveSelected(-1)
+	if len(t.history.undone) != 0 {
+		test.Errorf("%d changes to redo after a new one, want 0", len(t.history.undone))
+	}
+	t.redo()
+	checkTasks(test, t, []string{"b", "a"}, []string{})
+}
+
+func TestSelectionFollowsTask(test *testing.T) {
+	t := newTestTodo("a", "b", "c")
+	t.selectTask(2)
+	id := t.selected.id
+
+	t.selectTask(0)
+	t.archiveSelected()
+	if t.selected != nil || t.list.selected != nil {
+		test.Fatal("archived task still selected")
+	}
+
+	t.selectTask(1)
+	t.undo()
+	if t.selected == nil || t.selected.id != id {
+		test.Fatalf("selected %v, want task %d", t.selected, id)
+	}
+	if t.selected != t.tasks.getTask(2) || t.list.selected != &t.list.items[2] {
+		test.Error("selection not pointing at the moved task")
+	}
+
+	// The buffers growing move every task
+	for i := 0; i < initialTaskCap*2; i += 1 {
+		t.addTask(task{name: "more", sessionRequired: 1})
+	}
+	if t.selected != t.tasks.getTask(2) || t.selected.id != id {
+		test.Error("selection lost when the buffers grew")
+	}
+	if t.list.selected != &t.list.items[2] {
+		test.Error("selected row lost when the list grew")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checking twice a second is plenty when editing a file
+const themeWatchInterval = 30
+
+// Polls the theme files and the asset overrides, the theme being
+// loaded again as soon as one of them changes
+type themeWatcher struct {
+	ticks    int
+	modTimes map[string]time.Time
+}
+
+func (w *themeWatcher) init() {
+	w.modTimes = w.scan()
+}
+
+// Returns true when a file was changed, added or removed
+func (w *themeWatcher) update() bool {
+	w.ticks += 1
+	if w.ticks < themeWatchInterval {
+		return false
+	}
+	w.ticks = 0
+
+	modTimes := w.scan()
+	changed := len(modTimes) != len(w.modTimes)
+	for path, modTime := range modTimes {
+		if previous, exist := w.modTimes[path]; !exist || !previous.Equal(modTime) {
+			changed = true
+		}
+	}
+	w.modTimes = modTimes
+	return changed
+}
+
+func (w *themeWatcher) scan() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	var dirs []string
+	if dir, err := themesDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if dir, err := assetsOverrideDir(); err == nil {
+		dirs = append(dirs, dir, filepath.Join(dir, localesDirName))
+	}
+	for _, dir := range dirs {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || info.IsDir() {
+				continue
+			}
+			modTimes[filepath.Join(dir, entry.Name())] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// Colours, fonts, images and translations are all loaded again, a broken
+// theme being left out and a broken asset replaced by the default one
+func (t *Todo) reloadTheme() {
+	errs := loadThemes()
+	if !selectTheme(userSettings.Theme) {
+		selectTheme(darkTheme.Name)
+	}
+	for _, err := range errs {
+		t.toasts.push(tr("Theme {error}", "{error}", err.Error()))
+	}
+
+	assets.reload()
+	resetFallbackFonts()
+	defaultFont.setDPI(72 * uiScale)
+	t.font.setDPI(72 * uiScale)
+	for _, err := range loadLocales() {
+		t.toasts.push(tr("Language {error}", "{error}", err.Error()))
+	}
+	selectLanguage(userSettings.Language)
+	for _, err := range assets.takeErrors() {
+		t.toasts.push(tr("Asset {error}", "{error}", err.Error()))
+	}
+	t.relayout()
+}
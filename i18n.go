@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+const (
+	localesDirName = "locales"
+	defaultLocale  = "en"
+)
+
+// The messages are looked up by their english text, a missing one
+// being shown as is. The counted ones have a form for each plural
+// category of the language ("one", "few", "other"...)
+type catalog struct {
+	code string
+	tag  language.Tag
+
+	Name string `json:"name"`
+	// With {day} and {month}, the long one adding {year}
+	Date     string                     `json:"date"`
+	LongDate string                     `json:"longDate"`
+	Months   []string                   `json:"months"`
+	Messages map[string]json.RawMessage `json:"messages"`
+}
+
+var (
+	// English first, then by code
+	catalogs []catalog
+	locale   *catalog
+
+	// Automatic first, picked in the settings
+	languageNames  []string
+	languageChoice int
+)
+
+// The catalogs are the json files of the locales directory
+// of the assets, each one can be overridden
+func loadLocales() []error {
+	catalogs = nil
+	entries, err := embeddedAssets.ReadDir(path.Join(assetsDirName, localesDirName))
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		if path.Ext(name) != ".json" {
+			continue
+		}
+		c := catalog{code: strings.TrimSuffix(name, ".json")}
+		assets.decode(path.Join(localesDirName, name), func(data []byte) error {
+			c.Messages = nil
+			return json.Unmarshal(data, &c)
+		})
+		tag, err := language.Parse(c.code)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		c.tag = tag
+		if c.Name == "" {
+			c.Name = c.code
+		}
+		catalogs = append(catalogs, c)
+	}
+	sort.Slice(catalogs, func(i, j int) bool {
+		if catalogs[i].code == defaultLocale || catalogs[j].code == defaultLocale {
+			return catalogs[i].code == defaultLocale
+		}
+		return catalogs[i].code < catalogs[j].code
+	})
+
+	languageNames = []string{"Automatic"}
+	for i := range catalogs {
+		languageNames = append(languageNames, catalogs[i].Name)
+	}
+	return errs
+}
+
+// An empty code picks the closest to the one of the system
+func selectLanguage(code string) bool {
+	languageChoice = 0
+	for i := range catalogs {
+		if code != "" && catalogs[i].code == code {
+			languageChoice = i + 1
+		}
+	}
+	if len(catalogs) == 0 {
+		locale = &catalog{code: defaultLocale, tag: language.English}
+		return false
+	}
+	if code == "" {
+		code = detectLocale()
+	}
+
+	tags := make([]language.Tag, len(catalogs))
+	for i := range catalogs {
+		tags[i] = catalogs[i].tag
+	}
+	_, i, confidence := language.NewMatcher(tags).Match(language.Make(code))
+	locale = &catalogs[i]
+	return confidence != language.No
+}
+
+// The language chosen in the settings, by code
+func chosenLanguage() string {
+	if languageChoice == 0 || languageChoice > len(catalogs) {
+		return ""
+	}
+	return catalogs[languageChoice-1].code
+}
+
+// Strips the encoding of the POSIX locales, fr_FR.UTF-8 being fr-FR
+func normalizeLocale(s string) string {
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "C" || s == "POSIX" {
+		return ""
+	}
+	return strings.ReplaceAll(s, "_", "-")
+}
+
+func (c *catalog) message(key string) (json.RawMessage, bool) {
+	if msg, exist := c.Messages[key]; exist {
+		return msg, true
+	}
+	// The english one has the plural forms of the counted ones
+	if len(catalogs) > 0 && c != &catalogs[0] && catalogs[0].code == defaultLocale {
+		msg, exist := catalogs[0].Messages[key]
+		return msg, exist
+	}
+	return nil, false
+}
+
+// Translates the message, the arguments being pairs of
+// placeholders and values like in the notifications
+func tr(key string, args ...string) string {
+	text := key
+	if msg, exist := locale.message(key); exist {
+		json.Unmarshal(msg, &text)
+	}
+	return expandArgs(text, args)
+}
+
+// Picks the plural form for the count, which replaces {n}
+func trn(key string, n int, args ...string) string {
+	text := key
+	if msg, exist := locale.message(key); exist {
+		forms := map[string]string{}
+		if json.Unmarshal(msg, &forms) == nil {
+			if form, exist := forms[pluralForm(n)]; exist {
+				text = form
+			} else {
+				text = forms["other"]
+			}
+		} else {
+			json.Unmarshal(msg, &text)
+		}
+	}
+	return expandArgs(text, append([]string{"{n}", strconv.Itoa(n)}, args...))
+}
+
+func pluralForm(n int) string {
+	if n < 0 {
+		n = -n
+	}
+	switch plural.Cardinal.MatchPlural(locale.tag, n, 0, 0, 0, 0) {
+	case plural.Zero:
+		return "zero"
+	case plural.One:
+		return "one"
+	case plural.Two:
+		return "two"
+	case plural.Few:
+		return "few"
+	case plural.Many:
+		return "many"
+	}
+	return "other"
+}
+
+func expandArgs(text string, args []string) string {
+	if len(args) == 0 {
+		return text
+	}
+	return strings.NewReplacer(args...).Replace(text)
+}
+
+// Day and month, the year only when it isn't the current one
+func formatDate(t time.Time) string {
+	layout := locale.Date
+	if t.Year() != time.Now().Year() {
+		layout = locale.LongDate
+	}
+	month := t.Month().String()[:3]
+	if len(locale.Months) == 12 {
+		month = locale.Months[t.Month()-1]
+	}
+	return expandArgs(layout, []string{
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", month,
+		"{year}", strconv.Itoa(t.Year()),
+	})
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	idleChoiceKeep idleChoice = iota
+	idleChoiceDiscard
+	idleChoiceBreak
+)
+
+type (
+	// Pauses the work sessions when no input has been received
+	// for a while. Only the input reaching the app window is seen
+	idleDetector struct {
+		ticks int
+		// Only the ticks spent with a task at work
+		workTicks  int
+		lastCursor point
+		keys       []ebiten.Key
+
+		// Tasks paused by the detector, waiting for the user
+		// to decide what to do with the idle time
+		idle        bool
+		pausedTasks []int
+		// Time credited by the timers before the pause
+		counted seconds
+		before  seconds
+		away    seconds
+	}
+
+	idleChoice int
+)
+
+func (d *idleDetector) hasInput(mPos point) bool {
+	moved := mPos != d.lastCursor
+	d.lastCursor = mPos
+	if moved {
+		return true
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) ||
+		ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) ||
+		ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) {
+		return true
+	}
+	if x, y := ebiten.Wheel(); x != 0 || y != 0 {
+		return true
+	}
+	d.keys = inpututil.AppendPressedKeys(d.keys[:0])
+	return len(d.keys) > 0
+}
+
+func (d *idleDetector) idleTime() seconds {
+	return seconds(d.ticks / timerTicksPerSecond)
+}
+
+func (d *idleDetector) workTime() seconds {
+	return seconds(d.workTicks / timerTicksPerSecond)
+}
+
+func idleThreshold() int {
+	return userSettings.IdleMinutes * 60 * ebiten.MaxTPS()
+}
+
+func (t *Todo) updateIdle(mPos point) {
+	d := &t.idle
+	if d.hasInput(mPos) {
+		if d.idle {
+			d.idle = false
+			d.away = d.idleTime()
+			t.dialog.open(
+				tr("Welcome back"),
+				tr("You were away for {time}, what to do with that time?", "{time}", formatDuration(d.away)),
+				[]string{tr("Keep"), tr("Discard"), tr("Take as break")},
+				todoIdleResolved,
+			)
+		}
+		d.ticks = 0
+		d.workTicks = 0
+		return
+	}
+
+	d.ticks += 1
+	if d.idle || !userSettings.IdleDetection || t.dialog.active {
+		return
+	}
+	working := false
+	for i := 0; i < t.tasks.count; i += 1 {
+		if t.tasks.getTask(i).state == taskStateWork {
+			working = true
+		}
+	}
+	if !working {
+		return
+	}
+	d.workTicks += 1
+	if d.workTicks >= idleThreshold() {
+		d.pausedTasks = d.pausedTasks[:0]
+		for i := 0; i < t.tasks.count; i += 1 {
+			task := t.tasks.getTask(i)
+			if task.state == taskStateWork {
+				task.stopWork()
+				d.pausedTasks = append(d.pausedTasks, task.id)
+			}
+		}
+		if len(d.pausedTasks) > 0 {
+			d.idle = true
+			d.counted = d.workTime()
+			d.before = d.idleTime()
+		}
+	}
+}
+
+func (t *Todo) resolveIdle(choice idleChoice) {
+	d := &t.idle
+	for _, id := range d.pausedTasks {
+		for i := 0; i < t.tasks.count; i += 1 {
+			task := t.tasks.getTask(i)
+			if task.id == id {
+				task.resolveIdle(choice, d.away, d.away-d.before, d.counted)
+			}
+		}
+	}
+	d.pausedTasks = d.pausedTasks[:0]
+}
+
+// The timer kept running for the counted part of the idle time
+// before the detector kicked in, and was stopped for the paused part
+func (t *task) resolveIdle(choice idleChoice, idle, paused, counted seconds) {
+	t.idleTime += idle
+	switch choice {
+	case idleChoiceKeep:
+		// Only what the timer could still run, it stops at zero
+		before := t.timer.total()
+		t.timer.skip(paused)
+		extra := before - t.timer.total()
+		if t.timer.countUp {
+			extra = -extra
+		}
+		t.workedTime += extra
+		if t.overtime {
+			t.flowTime += extra
+		}
+		t.startWork()
+
+	case idleChoiceDiscard:
+		t.discardIdle(counted)
+		t.startWork()
+
+	case idleChoiceBreak:
+		t.discardIdle(counted)
+		t.startWork()
+		if t.kind == taskKindStopwatch {
+			return
+		}
+		if t.overtime {
+			t.takeBreak()
+		} else {
+			t.nextPhase()
+		}
+		if t.state == taskStateRest {
+			t.timer.skip(idle)
+		}
+	}
+}
+
+// Never rewinds past the start of the current phase
+func (t *task) discardIdle(counted seconds) {
+	if elapsed := t.phaseElapsed(); counted > elapsed {
+		counted = elapsed
+	}
+	t.timer.skip(-counted)
+	t.workedTime -= counted
+	if t.workedTime < 0 {
+		t.workedTime = 0
+	}
+	if t.overtime {
+		t.flowTime -= counted
+		if t.flowTime < 0 {
+			t.flowTime = 0
+		}
+	}
+}
+
+func (t *task) phaseElapsed() seconds {
+	switch {
+	case t.kind == taskKindStopwatch:
+		return t.timer.total()
+	case t.overtime:
+		return t.flowTime
+	default:
+		return t.phaseTotal - t.timer.total()
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"todo/anim"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
 const (
@@ -35,10 +34,8 @@ type (
 		shouldHighlight bool
 		highlightRect   rectangle
 
-		items       []listItem
-		count       int
-		cap         int
-		removeIndex int
+		items []listItem
+		count int
 	}
 
 	listItem struct {
@@ -46,22 +43,29 @@ type (
 		textPosition point
 		checkRect    rectangle
 		animations   [4]anim.Animation
+		// The full name when it had to be cut
+		tooltip string
 	}
 )
 
 func (l *listWindow) init(font *Font, outline *ebiten.Image) {
 	AddSignalListener(todoTaskRemoved, l)
-	l.rect = newRectLayout(rectangle{0, 0, 200, windowHeight})
-	l.addBtnRect = l.rect.cut(rectCutDown, textSize*2, 0)
-	l.listRect = l.rect.cut(rectCutUp, l.rect.remaining.height, 0)
+	AddSignalListener(todoTaskDeleted, l)
 	l.items = make([]listItem, initialTaskCap)
-	l.cap = initialTaskCap
 
 	l.font = font
 	l.rectOutline = outline
 	l.outlineConstr = constraint{2, 2, 2, 2}
 }
 
+// Called again every time the window or the list is resized
+func (l *listWindow) layout(bounds rectangle) {
+	l.rect = newRectLayout(bounds)
+	l.addBtnRect = l.rect.cut(rectCutDown, textSize*2, 0)
+	l.listRect = l.rect.cut(rectCutUp, l.rect.remaining.height, 0)
+	l.orderItems()
+}
+
 func (l *listWindow) update(mPos point, mLeft bool) (selected int) {
 	selected = -1
 	l.shouldHighlight = false
@@ -77,6 +81,9 @@ func (l *listWindow) update(mPos point, mLeft bool) (selected int) {
 				l.highlightRect = l.hovered.checkRect
 			} else {
 				l.highlightRect = l.hovered.rect
+				if l.hovered.tooltip != "" && !isInputHandled(mPos) {
+					showTooltip(l.hovered.tooltip, l.hovered.rect)
+				}
 			}
 			if mLeft {
 				selected = index
@@ -116,7 +123,7 @@ func (l *listWindow) update(mPos point, mLeft bool) (selected int) {
 
 func (l *listWindow) draw(dst *ebiten.Image, tasks []task) {
 	if l.shouldHighlight {
-		drawRect(dst, l.highlightRect, WhiteA125)
+		drawRect(dst, l.highlightRect, theme.Highlight)
 	}
 
 	for i := 0; i < l.count; i += 1 {
@@ -124,44 +131,49 @@ func (l *listWindow) draw(dst *ebiten.Image, tasks []task) {
 		task := &tasks[i]
 
 		// Draw progress in case it is running
-		if task.isInProgress() {
+		if task.isInProgress() || (task.kind == taskKindStopwatch && task.target > 0) {
 			progress := task.progress()
 			drawRect(dst, rectangle{
 				item.rect.x, item.rect.y,
 				item.rect.width * progress, item.rect.height,
-			}, WhiteA125)
+			}, theme.Progress)
 		}
 
+		name, cut := l.font.truncate(task.name, textSize, item.checkRect.x-itemPadding-item.textPosition[0])
+		item.tooltip = ""
+		if cut {
+			item.tooltip = task.name
+		}
 		drawText(dst, textOptions{
-			font: l.font, text: task.name, pos: item.textPosition,
-			size: textSize, clr: White,
+			font: l.font, text: name, pos: item.textPosition,
+			size: textSize, clr: theme.Text,
 		})
-		ebitenutil.DrawLine(
+		drawLine(
 			dst,
 			item.rect.x,
 			item.rect.y+item.rect.height,
 			item.rect.x+item.rect.width,
 			item.rect.y+item.rect.height,
-			darkSeparator,
+			theme.Separator,
 		)
 
-		drawImageSlice(dst, item.checkRect, l.rectOutline, l.outlineConstr, White)
+		drawImageSlice(dst, item.checkRect, l.rectOutline, l.outlineConstr, theme.Text)
 		if task.done {
 			rect := rectangle{item.checkRect.x + 3, item.checkRect.y + 3, item.checkRect.width - 6, item.checkRect.height - 6}
-			drawRect(dst, rect, White)
+			drawRect(dst, rect, theme.Text)
 		}
 	}
 
 	// drawTextBtn(dst, l.addBtnRect, "NewTask", textSize)
-	drawRect(dst, rectangle{l.addBtnRect.remaining.x, l.addBtnRect.remaining.y, l.addBtnRect.remaining.width, 1}, darkSeparator)
+	drawRect(dst, rectangle{l.addBtnRect.remaining.x, l.addBtnRect.remaining.y, l.addBtnRect.remaining.width, 1}, theme.Separator)
 	drawTextCenter(dst, textOptions{
-		font: l.font, text: "New Task", bounds: l.addBtnRect.remaining,
-		size: textSize, clr: White,
+		font: l.font, text: tr("New Task"), bounds: l.addBtnRect.remaining,
+		size: textSize, clr: theme.Text,
 	})
 }
 
 func (l *listWindow) addItem() {
-	rect := rectangle{0, float64(l.count) * itemHeight, 200, itemHeight}
+	rect := rectangle{0, float64(l.count) * itemHeight, l.rect.full.width, itemHeight}
 	textPos := point{rect.x + itemPadding, rect.y + itemPadding}
 	i := listItem{
 		rect:         rect,
@@ -173,10 +185,12 @@ func (l *listWindow) addItem() {
 			textSize - itemPadding,
 		},
 	}
-	if l.count > len(l.items) {
-		newSlice := make([]listItem, l.cap*2)
+	if l.count >= len(l.items) {
+		newSlice := make([]listItem, len(l.items)*2)
 		copy(newSlice[:], l.items[:])
 		l.items = newSlice
+		// The animations still point to the old items
+		l.orderItems()
 	}
 	l.items[l.count] = i
 	l.count += 1
@@ -188,19 +202,19 @@ func (l *listWindow) addItem() {
 			anim.NewAnimation("hoverStart", l),
 			anim.NewAnimation("hoverEnd", l),
 		}
-		li.animations[listItemAddAnimation].AddProperty("rectx", &li.rect.x, li.rect.x-200, false)
+		li.animations[listItemAddAnimation].AddProperty("rectx", &li.rect.x, li.rect.x-li.rect.width, false)
 		li.animations[listItemAddAnimation].AddKey("rectx", anim.AnimationKey{
 			Easing:   anim.EaseOutCubic,
 			Duration: anim.SecondsToTicks(0.2),
 			Change:   li.rect.width,
 		})
-		li.animations[listItemAddAnimation].AddProperty("textx", &li.textPosition[0], li.textPosition[0]-200, false)
+		li.animations[listItemAddAnimation].AddProperty("textx", &li.textPosition[0], li.textPosition[0]-li.rect.width, false)
 		li.animations[listItemAddAnimation].AddKey("textx", anim.AnimationKey{
 			Easing:   anim.EaseOutCubic,
 			Duration: anim.SecondsToTicks(0.2),
 			Change:   li.rect.width,
 		})
-		li.animations[listItemAddAnimation].AddProperty("checkrectx", &li.checkRect.x, li.checkRect.x-200, false)
+		li.animations[listItemAddAnimation].AddProperty("checkrectx", &li.checkRect.x, li.checkRect.x-li.rect.width, false)
 		li.animations[listItemAddAnimation].AddKey("checkrectx", anim.AnimationKey{
 			Easing:   anim.EaseOutCubic,
 			Duration: anim.SecondsToTicks(0.2),
@@ -239,15 +253,28 @@ func (l *listWindow) addItem() {
 	}(&l.items[l.count-1])
 }
 
+// At the index, or at the end when past it. The rows below
+// move down and the new one slides in at its place
+func (l *listWindow) insertItem(at int) {
+	l.addItem()
+	if at < 0 || at >= l.count-1 {
+		return
+	}
+	added := l.items[l.count-1]
+	copy(l.items[at+1:l.count], l.items[at:l.count-1])
+	l.items[at] = added
+	l.orderItems()
+}
+
 func (l *listWindow) removeItem(at int) {
-	copy(l.items[l.removeIndex:], l.items[l.removeIndex+1:])
+	copy(l.items[at:], l.items[at+1:])
 	l.count -= 1
 	l.orderItems()
 }
 
 func (l *listWindow) orderItems() {
 	for i := 0; i < l.count; i += 1 {
-		rect := rectangle{0, float64(i * itemHeight), 200, itemHeight}
+		rect := rectangle{0, float64(i * itemHeight), l.rect.full.width, itemHeight}
 		textPos := point{rect.x + itemPadding, rect.y + itemPadding}
 		item := &l.items[i]
 		item.rect = rect
@@ -266,12 +293,13 @@ func (l *listWindow) orderItems() {
 		item.animations[listItemRemoveAnimation].SetPropertyRef("textx", &item.textPosition[0])
 		item.animations[listItemRemoveAnimation].SetPropertyRef("checkrectx", &item.checkRect.x)
 
+		item.animations[listItemHoverAnimation].SetPropertyRef("textx", &item.textPosition[0])
 	}
 }
 
 func (l *listWindow) OnSignal(s Signal) {
 	switch s.Kind {
-	case todoTaskRemoved:
+	case todoTaskRemoved, todoTaskDeleted:
 		l.selected.animations[listItemRemoveAnimation].Play()
 	}
 }
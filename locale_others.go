@@ -0,0 +1,27 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// The usual POSIX variables, the apps started from
+// the finder on macOS not getting them
+func detectLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if l := normalizeLocale(os.Getenv(name)); l != "" {
+			return l
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		out, err := exec.Command("defaults", "read", "-g", "AppleLocale").Output()
+		if err == nil {
+			return normalizeLocale(strings.TrimSpace(string(out)))
+		}
+	}
+	return defaultLocale
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// The environment is rarely set on windows, the
+// user locale comes from the system instead
+func detectLocale() string {
+	buf := make([]uint16, 85)
+	proc := syscall.NewLazyDLL("kernel32.dll").NewProc("GetUserDefaultLocaleName")
+	if n, _, _ := proc.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf))); n == 0 {
+		return defaultLocale
+	}
+	return syscall.UTF16ToString(buf)
+}
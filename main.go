@@ -5,12 +5,15 @@ import (
 )
 
 func main() {
-	ebiten.SetWindowSize(windowWidth, windowHeight)
-	ebiten.SetWindowTitle("Get work done")
-
 	todo := new(Todo)
 	todo.Init()
 
+	// Sized after the settings are loaded for the user scale
+	ebiten.SetWindowSize(int(windowWidth*userScale()), int(windowHeight*userScale()))
+	ebiten.SetWindowResizable(true)
+	setWindowSizeLimits()
+	ebiten.SetWindowTitle(tr(windowTitle))
+
 	if err := ebiten.RunGame(todo); err != nil {
 		e := err.(exitStatus)
 		if e.kind != exitNoError {
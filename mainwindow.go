@@ -2,9 +2,10 @@ package main
 
 import (
 	_ "image/png"
+	"strconv"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 const (
@@ -13,24 +14,66 @@ const (
 	timerBtnID
 	taskSettingsBtnID
 	archiveTaskBtnID
+	statsBtnID
+	noiseDecColorID
+	noiseIncColorID
+	noiseDecVolumeID
+	noiseIncVolumeID
+	focusBtnID
+	notesID
+	miniBtnID
+	deleteTaskBtnID
+	sessionDecID
+	sessionIncID
 )
 
 const (
 	timerStartStr = "Start"
 	timerStopStr  = "Stop"
+	timerBreakStr = "Take a Break"
 )
 
 type mainWindow struct {
 	rect                rectLayout
 	settingsBtnRect     rectLayout
 	archiveBtnRect      rectLayout
+	statsBtnRect        rectLayout
+	focusBtnRect        rectLayout
+	miniBtnRect         rectLayout
 	titleRect           rectLayout
+	notesRect           rectLayout
 	progressRect        rectLayout
 	workTimerRect       rectLayout
 	restTimerRect       rectLayout
 	timerBtnRect        rectLayout
+	phasesRect          rectLayout
 	taskSettingsBtnRect rectLayout
 	archiveTaskBtnRect  rectLayout
+	deleteTaskBtnRect   rectLayout
+
+	// On each side of the progress bar
+	decSessionsRect rectangle
+	incSessionsRect rectangle
+
+	warning timerWarning
+
+	// The full name when the title had to be cut
+	titleTooltip string
+
+	// The notes of the selected task being typed
+	notesSelected bool
+	notesTask     *task
+	// For undoing the whole edit at once
+	notesBefore string
+	runes       []rune
+
+	// Ambient noise controls
+	noiseColorRect     rectLayout
+	decNoiseColorRect  rectangle
+	incNoiseColorRect  rectangle
+	noiseVolumeRect    rectLayout
+	decNoiseVolumeRect rectangle
+	incNoiseVolumeRect rectangle
 
 	settingElements rectArray
 	infoElements    rectArray
@@ -42,70 +85,128 @@ type mainWindow struct {
 	outlineConstr constraint
 	archiveIcon   *ebiten.Image
 	settingsIcon  *ebiten.Image
+	statsIcon     *ebiten.Image
+	focusIcon     *ebiten.Image
+	miniIcon      *ebiten.Image
 }
 
 func (m *mainWindow) init(font *Font, outline *ebiten.Image) {
-	const mainWindowPadding = 10
-	const mainWindowNoPadding = 0
 	m.settingElements.init(m, 5)
 	m.infoElements.init(m, 5)
 
-	m.rect = newRectLayout(rectangle{200, 0, 600, windowHeight})
+	m.font = font
+	m.rectOutline = outline
+	m.outlineConstr = constraint{2, 2, 2, 2}
+
+	m.archiveIcon = assets.image("icon-archive.png")
+	m.settingsIcon = assets.image("icon-settings.png")
+	m.statsIcon = assets.image("icon-stats.png")
+	m.focusIcon = assets.image("icon-focus.png")
+	m.miniIcon = assets.image("icon-mini.png")
+
+	m.warning.init()
+}
+
+// Called again every time the window or the list is resized
+func (m *mainWindow) layout(bounds rectangle) {
+	const mainWindowPadding = 10
+	const mainWindowNoPadding = 0
+	m.settingElements.rects = m.settingElements.rects[:0]
+	m.infoElements.rects = m.infoElements.rects[:0]
+
+	m.rect = newRectLayout(bounds)
 	m.rect.cut(rectCutUp, mainWindowPadding, 0)
 
 	settingsRect := m.rect.cut(rectCutUp, 30, mainWindowPadding)
 	settingsRect.cut(rectCutRight, mainWindowPadding, 0)
 	m.settingsBtnRect = settingsRect.cut(rectCutRight, 30, mainWindowPadding)
 	m.archiveBtnRect = settingsRect.cut(rectCutRight, 30, mainWindowPadding)
+	m.statsBtnRect = settingsRect.cut(rectCutRight, 30, mainWindowPadding)
 	m.settingElements.add(m.settingsBtnRect.remaining, settingsBtnID)
 	m.settingElements.add(m.archiveBtnRect.remaining, archiveBtnID)
+	m.settingElements.add(m.statsBtnRect.remaining, statsBtnID)
+	m.miniBtnRect = settingsRect.cut(rectCutRight, 30, mainWindowPadding)
+	m.settingElements.add(m.miniBtnRect.remaining, miniBtnID)
+	m.focusBtnRect = settingsRect.cut(rectCutRight, 30, mainWindowPadding)
+	m.infoElements.add(m.focusBtnRect.remaining, focusBtnID)
 
-	m.titleRect = m.rect.cut(rectCutUp, 80, mainWindowPadding+10)
+	m.titleRect = m.rect.cut(rectCutUp, 80, mainWindowPadding)
+	m.notesRect = m.rect.cut(rectCutUp, 24, mainWindowPadding+10)
+	m.notesRect.keepCenter(400)
+	m.infoElements.add(m.notesRect.remaining, notesID)
 
 	m.progressRect = m.rect.cut(rectCutUp, 50, mainWindowPadding)
-	m.progressRect.cut(rectCutRight, 200, 0)
-	m.progressRect.cut(rectCutLeft, 200, 0)
+	m.progressRect.keepCenter(200)
+	advance := m.font.GlyphAdvance('>', textSize) + 3
+	bar := m.progressRect.remaining
+	m.decSessionsRect = rectangle{bar.x - advance - mainWindowPadding, bar.y, advance, bar.height}
+	m.incSessionsRect = rectangle{bar.x + bar.width + mainWindowPadding, bar.y, advance, bar.height}
+	m.infoElements.add(m.decSessionsRect, sessionDecID)
+	m.infoElements.add(m.incSessionsRect, sessionIncID)
 
 	timerRect := m.rect.cut(rectCutUp, 100, mainWindowPadding)
-	timerRect.cut(rectCutRight, 100, 0)
-	timerRect.cut(rectCutLeft, 100, 0)
+	timerRect.keepCenter(400)
 	timerWidth := (timerRect.remaining.width - 5) / 2
 	m.workTimerRect = timerRect.cut(rectCutLeft, timerWidth, mainWindowNoPadding)
 	m.restTimerRect = timerRect.cut(rectCutRight, timerWidth, mainWindowNoPadding)
 
 	m.timerBtnRect = m.rect.cut(rectCutUp, 50, mainWindowPadding)
-	m.timerBtnRect.cut(rectCutRight, 200, 0)
-	m.timerBtnRect.cut(rectCutLeft, 200, 0)
+	m.timerBtnRect.keepCenter(200)
 	m.infoElements.add(m.timerBtnRect.remaining, timerBtnID)
 
+	m.phasesRect = m.rect.cut(rectCutUp, 50, mainWindowPadding)
+	m.phasesRect.cut(rectCutRight, 50, 0)
+	m.phasesRect.cut(rectCutLeft, 50, 0)
+
 	m.rect.cut(rectCutDown, mainWindowPadding, 0)
 	taskSettingsRect := m.rect.cut(rectCutDown, 30, mainWindowPadding)
 	taskSettingsRect.cut(rectCutRight, mainWindowPadding, 0)
 	m.archiveTaskBtnRect = taskSettingsRect.cut(
 		rectCutRight,
-		font.MeasureText("Archive Task", textSize)[0]+mainWindowPadding*2,
+		m.font.MeasureText(tr("Archive Task"), textSize)[0]+mainWindowPadding*2,
+		mainWindowPadding,
+	)
+	m.deleteTaskBtnRect = taskSettingsRect.cut(
+		rectCutRight,
+		m.font.MeasureText(tr("Delete Task"), textSize)[0]+mainWindowPadding*2,
 		mainWindowPadding,
 	)
 	m.taskSettingsBtnRect = taskSettingsRect.cut(rectCutRight, 30, mainWindowPadding)
 	m.infoElements.add(m.archiveTaskBtnRect.remaining, archiveTaskBtnID)
+	m.infoElements.add(m.deleteTaskBtnRect.remaining, deleteTaskBtnID)
 	m.infoElements.add(m.taskSettingsBtnRect.remaining, taskSettingsBtnID)
 
-	m.font = font
-	m.rectOutline = outline
-	m.outlineConstr = constraint{2, 2, 2, 2}
-
-	m.archiveIcon, _, _ = ebitenutil.NewImageFromFile("assets/icon-archive.png")
-	m.settingsIcon, _, _ = ebitenutil.NewImageFromFile("assets/icon-settings.png")
+	taskSettingsRect.cut(rectCutLeft, mainWindowPadding, 0)
+	m.noiseColorRect = taskSettingsRect.cut(rectCutLeft, 150, mainWindowPadding)
+	m.decNoiseColorRect = m.noiseColorRect.cut(rectCutLeft, advance, 0).full
+	m.incNoiseColorRect = m.noiseColorRect.cut(rectCutRight, advance, 0).full
+	m.noiseVolumeRect = taskSettingsRect.cut(rectCutLeft, 80, mainWindowPadding)
+	m.decNoiseVolumeRect = m.noiseVolumeRect.cut(rectCutLeft, advance, 0).full
+	m.incNoiseVolumeRect = m.noiseVolumeRect.cut(rectCutRight, advance, 0).full
+	m.settingElements.add(m.decNoiseColorRect, noiseDecColorID)
+	m.settingElements.add(m.incNoiseColorRect, noiseIncColorID)
+	m.settingElements.add(m.decNoiseVolumeRect, noiseDecVolumeID)
+	m.settingElements.add(m.incNoiseVolumeRect, noiseIncVolumeID)
 }
 
 func (m *mainWindow) update(mPos point, mLeft bool, task *task) {
+	m.warning.update()
+	if m.notesSelected {
+		m.updateNotes(mPos, mLeft, task)
+	}
 	if !isInputHandled(mPos) {
 		if task != nil {
+			m.notesTask = task
 			m.infoElements.update(mPos, mLeft)
-			switch task.timer.running {
-			case true:
+			if m.titleTooltip != "" && m.titleRect.remaining.boundCheck(mPos) {
+				showTooltip(m.titleTooltip, m.titleRect.remaining)
+			}
+			switch {
+			case task.overtime && task.timer.running:
+				m.timerStr = timerBreakStr
+			case task.timer.running:
 				m.timerStr = timerStopStr
-			case false:
+			default:
 				m.timerStr = timerStartStr
 			}
 		}
@@ -113,101 +214,383 @@ func (m *mainWindow) update(mPos point, mLeft bool, task *task) {
 	}
 }
 
+// Typing in the notes line, done with enter
+// or by clicking somewhere else
+func (m *mainWindow) updateNotes(mPos point, mLeft bool, task *task) {
+	const maxNotesLength = 80
+
+	if task == nil || task != m.notesTask || inpututil.IsKeyJustPressed(ebiten.KeyEnter) ||
+		(mLeft && !m.notesRect.remaining.boundCheck(mPos)) {
+		m.notesSelected = false
+		if m.notesTask != nil && m.notesTask.notes != m.notesBefore {
+			FireSignal(todoTaskEdited, &editNotesCommand{
+				id: m.notesTask.id, name: m.notesTask.name,
+				before: m.notesBefore, after: m.notesTask.notes,
+			})
+		}
+		return
+	}
+	notes := []rune(task.notes)
+	m.runes = ebiten.AppendInputChars(m.runes[:0])
+	for _, r := range m.runes {
+		if len(notes) < maxNotesLength {
+			notes = append(notes, r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(notes) > 0 {
+		notes = notes[:len(notes)-1]
+	}
+	task.notes = string(notes)
+}
+
 func (m *mainWindow) draw(dst *ebiten.Image, task *task) {
-	drawRect(dst, m.rect.full, darkBackground2)
-	ebitenutil.DrawLine(
+	drawRect(dst, m.rect.full, theme.Background2)
+	drawLine(
 		dst,
 		m.rect.full.x,
 		m.rect.full.y,
 		m.rect.full.x,
 		m.rect.full.y+m.rect.full.height,
-		darkSeparator,
+		theme.Separator,
 	)
 	m.settingElements.highlight(dst)
 
 	drawIcontBtn(dst, m.settingsBtnRect.remaining, m.settingsIcon)
 	drawIcontBtn(dst, m.archiveBtnRect.remaining, m.archiveIcon)
+	drawIcontBtn(dst, m.statsBtnRect.remaining, m.statsIcon)
+	drawIcontBtn(dst, m.miniBtnRect.remaining, m.miniIcon)
+
+	drawChoice(
+		dst, m.noiseColorRect.full,
+		m.incNoiseColorRect, m.decNoiseColorRect,
+		tr(noiseNames[userSettings.Noise]),
+	)
+	drawChoice(
+		dst, m.noiseVolumeRect.full,
+		m.incNoiseVolumeRect, m.decNoiseVolumeRect,
+		strconv.Itoa(userSettings.NoiseVolume)+"%",
+	)
 
 	// Task info widgets
 	if task != nil {
 		m.infoElements.highlight(dst)
+		drawIcontBtn(dst, m.focusBtnRect.remaining, m.focusIcon)
+		m.drawNotes(dst, task)
+
+		m.drawTitle(dst, task)
+		if task.kind == taskKindStopwatch {
+			m.drawStopwatch(dst, task)
+		} else {
+			m.drawSessions(dst, task)
+		}
 
+		// Could probably cache this string
+		// maybe no allocations are even happening.. who knows
+		if m.timerStr == timerBreakStr {
+			drawTextBtn(dst, m.timerBtnRect.remaining, tr(m.timerStr), textSize)
+		} else {
+			drawTextBtn(dst, m.timerBtnRect.remaining, tr(m.timerStr+" Timer"), textSize)
+		}
+
+		drawTextBtn(dst, m.archiveTaskBtnRect.remaining, tr("Archive Task"), textSize)
+		drawTextBtn(dst, m.deleteTaskBtnRect.remaining, tr("Delete Task"), textSize)
+		drawIcontBtn(dst, m.taskSettingsBtnRect.remaining, m.archiveIcon)
+	}
+}
+
+// The name gets smaller to fit, then goes on two lines
+func (m *mainWindow) drawTitle(dst *ebiten.Image, task *task) {
+	const titleMaxLines = 2
+
+	bounds := m.titleRect.remaining
+	m.titleTooltip = ""
+	size, fits := m.font.shrinkToFit(task.name, bounds.width, largeTextSize, textSize)
+	if fits {
 		drawTextCenter(dst, textOptions{
-			font: m.font, text: task.name, bounds: m.titleRect.remaining,
-			size: largeTextSize, clr: White,
+			font: m.font, text: task.name, bounds: bounds,
+			size: size, clr: theme.Text,
 		})
-		// drawRect(dst, m.progressRect.remaining, White)
-		drawImageSlice(dst, m.progressRect.remaining, rectOutline, rectConstraint, White)
-		// Draw the progress bars here
-		{
-			insideRect := m.progressRect.remaining
-			insideRect.x += 3
-			insideRect.y += 3
-			insideRect.width -= 4
-			insideRect.height -= 6
-			barWidth := (insideRect.width - float64(2*task.sessionRequired)) / float64(task.sessionRequired)
-			xptr := insideRect.x
-			for i := 0; i < task.sessionCompleted; i += 1 {
-				drawRect(dst, rectangle{xptr, insideRect.y, barWidth, insideRect.height}, White)
-				xptr += barWidth + 2
-			}
+		return
+	}
+	lines, cut := m.font.wrap(task.name, textSize, bounds.width, titleMaxLines)
+	if cut {
+		m.titleTooltip = task.name
+	}
+	lineHeight := float64(textSize + 6)
+	y := bounds.y + (bounds.height-lineHeight*float64(len(lines)))/2
+	for i, line := range lines {
+		drawTextCenter(dst, textOptions{
+			font: m.font, text: line,
+			bounds: rectangle{bounds.x, y + float64(i)*lineHeight, bounds.width, lineHeight},
+			size:   textSize, clr: theme.Text,
+		})
+	}
+}
+
+func (m *mainWindow) drawSessions(dst *ebiten.Image, task *task) {
+	// drawRect(dst, m.progressRect.remaining, theme.Text)
+	drawImageSlice(dst, m.progressRect.remaining, rectOutline, rectConstraint, theme.Text)
+	// Draw the progress bars here
+	{
+		insideRect := m.progressRect.remaining
+		insideRect.x += 3
+		insideRect.y += 3
+		insideRect.width -= 4
+		insideRect.height -= 6
+		barWidth := (insideRect.width - float64(2*task.sessionRequired)) / float64(task.sessionRequired)
+		xptr := insideRect.x
+		for i := 0; i < task.sessionCompleted && i < task.sessionRequired; i += 1 {
+			drawRect(dst, rectangle{xptr, insideRect.y, barWidth, insideRect.height}, theme.Text)
+			xptr += barWidth + 2
 		}
+	}
+	drawTextCenter(dst, textOptions{
+		font: m.font, text: "<", bounds: m.decSessionsRect,
+		size: textSize, clr: theme.MutedText,
+	})
+	drawTextCenter(dst, textOptions{
+		font: m.font, text: ">", bounds: m.incSessionsRect,
+		size: textSize, clr: theme.MutedText,
+	})
 
-		if task.isWorkInProgress() {
-			progress := task.progress()
-			drawRect(dst, rectangle{
-				m.workTimerRect.remaining.x, m.workTimerRect.remaining.y,
-				m.workTimerRect.remaining.width * progress, m.workTimerRect.remaining.height,
-			}, WhiteA125)
-		}
-		drawTextBtn(dst, m.workTimerRect.remaining, task.getWorkTime(), largeTextSize)
-		drawImage(
-			dst, timerWorkIcon,
-			point{
-				m.workTimerRect.x() + m.workTimerRect.width()/2 - 8,
-				m.workTimerRect.y() + 8,
-			},
-			WhiteA125,
-		)
+	// Estimate versus actual
+	estimateText := tr("Worked {worked} of {estimated} estimated",
+		"{worked}", formatDuration(task.workedTime),
+		"{estimated}", formatDuration(task.estimatedTime()),
+	)
+	if extra := task.extraSessions(); extra > 0 {
+		estimateText += " " + trn("(+{n} sessions)", extra)
+	}
+	if flow := task.totalFlowTime + task.flowTime; flow > 0 {
+		estimateText += tr(", {time} in flow", "{time}", formatDuration(flow))
+	}
+	drawTextCenter(dst, textOptions{
+		font: m.font, text: estimateText,
+		bounds: rectangle{
+			m.titleRect.x(), m.titleRect.y() + m.titleRect.height() - smallTextSize,
+			m.titleRect.width(), smallTextSize,
+		},
+		size: smallTextSize, clr: theme.MutedText,
+	})
+
+	workClr := theme.Text
+	if task.overtime {
+		workClr = theme.Flow
+	}
+	workIcon := timerWorkIcon
+	if index := task.phaseOfKind(phaseWork); index >= 0 {
+		workIcon = task.phases[index].icon
+	}
+	m.drawTimer(dst, m.workTimerRect, task, task.isWorkInProgress(), task.getWorkTime(), workClr, workIcon)
+
+	restIcon := timerRestIcon
+	if index := task.phaseOfKind(phaseRest); index >= 0 {
+		restIcon = task.phases[index].icon
+	}
+	m.drawTimer(dst, m.restTimerRect, task, task.isRestInProgress(), task.getRestTime(), theme.Text, restIcon)
 
-		if task.isRestInProgress() {
-			progress := task.progress()
+	m.drawPhases(dst, task)
+}
+
+func (m *mainWindow) drawNotes(dst *ebiten.Image, task *task) {
+	text, clr := task.notes, theme.Text
+	if m.notesSelected {
+		drawImageSlice(dst, m.notesRect.remaining, rectOutline, rectConstraint, theme.MutedText)
+		text += "|"
+	} else if text == "" {
+		text, clr = tr("Add a note"), theme.MutedText
+	}
+	drawTextCenter(dst, textOptions{
+		font: m.font, text: text, bounds: m.notesRect.remaining,
+		size: smallTextSize, clr: clr,
+	})
+}
+
+// Draws either the digits over the progress or the pie,
+// active being true for the timer of the current phase
+func (m *mainWindow) drawTimer(dst *ebiten.Image, rect rectLayout, task *task, active bool, text string, clr Color, icon *ebiten.Image) {
+	iconPos := point{rect.x() + rect.width()/2 - 8, rect.y() + 8}
+	switch userSettings.TimerStyle {
+	case timerStylePie:
+		remaining := 1.0
+		pieClr := theme.MutedText
+		if active {
+			remaining = 1 - task.progress()
+			pieClr = rampColor(task, *task.currentPhase().clr)
+		}
+		if task.overtime && active {
+			// Nothing left, the whole disc shows the flow time
+			remaining = 1
+			pieClr = theme.Flow
+		}
+		digits := ""
+		if userSettings.TimerDigits {
+			digits = text
+		}
+		drawImageSlice(dst, rect.remaining, rectOutline, rectConstraint, clr)
+		drawTimerPie(dst, rect.remaining, remaining, pieClr, theme.Background2, digits, smallTextSize)
+		iconPos = point{rect.x() + 8, rect.y() + 8}
+	default:
+		if active {
 			drawRect(dst, rectangle{
-				m.restTimerRect.remaining.x, m.restTimerRect.remaining.y,
-				m.restTimerRect.remaining.width * progress, m.restTimerRect.remaining.height,
-			}, WhiteA125)
-		}
-		drawTextBtn(dst, m.restTimerRect.remaining, task.getRestTime(), largeTextSize)
-		drawImage(
-			dst, timerRestIcon,
-			point{
-				m.restTimerRect.x() + m.restTimerRect.width()/2 - 8,
-				m.restTimerRect.y() + 8,
-			},
-			WhiteA125,
+				rect.remaining.x, rect.remaining.y,
+				rect.remaining.width * task.progress(), rect.remaining.height,
+			}, progressColor(task))
+		}
+		drawColoredTextBtn(dst, rect.remaining, text, largeTextSize, clr)
+	}
+	if active {
+		m.warning.draw(dst, task, rect.remaining)
+	}
+	drawImage(dst, icon, iconPos, theme.MutedText)
+}
+
+// Stopwatch tasks only show the accumulated time and the
+// optional target, filling the progress bar
+func (m *mainWindow) drawStopwatch(dst *ebiten.Image, task *task) {
+	drawImageSlice(dst, m.progressRect.remaining, rectOutline, rectConstraint, theme.Text)
+	if task.target > 0 {
+		insideRect := m.progressRect.remaining
+		insideRect.x += 3
+		insideRect.y += 3
+		insideRect.width -= 6
+		insideRect.height -= 6
+		insideRect.width *= task.progress()
+		drawRect(dst, insideRect, theme.Text)
+	}
+
+	trackedText := tr("Tracked {time}", "{time}", formatDuration(task.workedTime))
+	if task.target > 0 {
+		trackedText = tr("Tracked {time} of a {target} target",
+			"{time}", formatDuration(task.workedTime),
+			"{target}", formatDuration(task.estimatedTime()),
 		)
+	}
+	drawTextCenter(dst, textOptions{
+		font: m.font, text: trackedText,
+		bounds: rectangle{
+			m.titleRect.x(), m.titleRect.y() + m.titleRect.height() - smallTextSize,
+			m.titleRect.width(), smallTextSize,
+		},
+		size: smallTextSize, clr: theme.MutedText,
+	})
 
-		// Could probably cache this string
-		// maybe no allocations are even happening.. who knows
-		drawTextBtn(dst, m.timerBtnRect.remaining, m.timerStr+" Timer", textSize)
+	drawTextBtn(dst, m.workTimerRect.remaining, task.getWorkTime(), largeTextSize)
+	drawImage(
+		dst, timerWorkIcon,
+		point{
+			m.workTimerRect.x() + m.workTimerRect.width()/2 - 8,
+			m.workTimerRect.y() + 8,
+		},
+		theme.MutedText,
+	)
 
-		drawTextBtn(dst, m.archiveTaskBtnRect.remaining, "Archive Task", textSize)
-		drawIcontBtn(dst, m.taskSettingsBtnRect.remaining, m.archiveIcon)
+	targetText := "--:--"
+	if task.target > 0 {
+		targetText = formatClock(task.estimatedTime())
+	}
+	drawTextBtn(dst, m.restTimerRect.remaining, targetText, largeTextSize)
+	drawTextCenter(dst, textOptions{
+		font: m.font, text: tr("target"),
+		bounds: rectangle{
+			m.restTimerRect.x(), m.restTimerRect.y() + 4,
+			m.restTimerRect.width(), smallTextSize,
+		},
+		size: smallTextSize, clr: theme.MutedText,
+	})
+}
+
+// Current phase followed by the upcoming ones of the session
+func (m *mainWindow) drawPhases(dst *ebiten.Image, task *task) {
+	const phaseWidth = 80
+	const phaseSpacing = 6
+
+	bounds := m.phasesRect.remaining
+	x := bounds.x
+	for i := task.phaseIndex; i < len(task.phases); i += 1 {
+		if x+phaseWidth > bounds.x+bounds.width {
+			drawText(dst, textOptions{
+				font: m.font, text: "...", pos: point{x, bounds.y + bounds.height/2 - smallTextSize/2},
+				size: smallTextSize, clr: theme.MutedText,
+			})
+			break
+		}
+		p := &task.phases[i]
+		rect := rectangle{x, bounds.y, phaseWidth, bounds.height}
+		clr := theme.MutedText
+		if i == task.phaseIndex && task.isInProgress() {
+			clr = *p.clr
+			drawRect(dst, rect, theme.Background3)
+		}
+		drawImageSlice(dst, rect, m.rectOutline, m.outlineConstr, clr)
+		drawTextCenter(dst, textOptions{
+			font: m.font, text: tr(p.name),
+			bounds: rectangle{rect.x, rect.y + 4, rect.width, rect.height / 2},
+			size:   smallTextSize, clr: clr,
+		})
+		drawTextCenter(dst, textOptions{
+			font: m.font, text: strconv.Itoa(int(p.length)) + "m",
+			bounds: rectangle{rect.x, rect.y + rect.height/2, rect.width, rect.height / 2},
+			size:   smallTextSize, clr: clr,
+		})
+		x += phaseWidth + phaseSpacing
 	}
 }
 
 func (m *mainWindow) onClick(userID rectID) {
 	switch userID {
+	case noiseDecColorID, noiseIncColorID:
+		count := len(noiseNames)
+		if userID == noiseIncColorID {
+			userSettings.Noise = (userSettings.Noise + 1) % count
+		} else {
+			userSettings.Noise = (userSettings.Noise - 1 + count) % count
+		}
+		storeSettings()
+	case noiseDecVolumeID, noiseIncVolumeID:
+		if userID == noiseIncVolumeID {
+			userSettings.NoiseVolume += 10
+		} else {
+			userSettings.NoiseVolume -= 10
+		}
+		if userSettings.NoiseVolume < 0 {
+			userSettings.NoiseVolume = 0
+		}
+		if userSettings.NoiseVolume > 100 {
+			userSettings.NoiseVolume = 100
+		}
+		storeSettings()
+	case settingsBtnID:
+		FireSignal(todoSettingsBtnPressed, SignalNoArgs)
+	case focusBtnID:
+		FireSignal(todoFocusBtnPressed, SignalNoArgs)
+	case miniBtnID:
+		FireSignal(todoMiniBtnPressed, SignalNoArgs)
+	case notesID:
+		if !m.notesSelected {
+			m.notesBefore = m.notesTask.notes
+		}
+		m.notesSelected = true
 	case archiveBtnID:
 		FireSignal(todoArchiveBtnPressed, SignalNoArgs)
+	case statsBtnID:
+		FireSignal(todoStatsBtnPressed, SignalNoArgs)
 	case timerBtnID:
 		switch m.timerStr {
 		case timerStartStr:
 			FireSignal(todoTaskStarted, SignalNoArgs)
 		case timerStopStr:
 			FireSignal(todoTaskStopped, SignalNoArgs)
+		case timerBreakStr:
+			FireSignal(todoTaskBreakTaken, SignalNoArgs)
 		}
 	case archiveTaskBtnID:
 		FireSignal(todoTaskRemoved, SignalNoArgs)
+	case deleteTaskBtnID:
+		FireSignal(todoTaskDeleted, SignalNoArgs)
+	case sessionDecID:
+		FireSignal(todoSessionsChanged, SignalInt(-1))
+	case sessionIncID:
+		FireSignal(todoSessionsChanged, SignalInt(1))
 	}
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	miniWindowWidth  = 320
+	miniWindowHeight = 64
+)
+
+// Small floating strip showing the running task. Dragging
+// it moves the window, a click brings the full layout back
+type miniWindow struct {
+	active bool
+
+	rect         rectLayout
+	nameRect     rectLayout
+	phaseRect    rectLayout
+	timeRect     rectLayout
+	progressRect rectLayout
+
+	// Where the full window was before shrinking
+	restorePos  point
+	restoreSize point
+
+	// Only a press made on the strip counts, the one
+	// on the button opening it is still held
+	pressed   bool
+	dragStart point
+	dragged   bool
+
+	font *Font
+}
+
+func (m *miniWindow) init(font *Font) {
+	const miniPadding = 8
+
+	m.font = font
+	m.rect = newRectLayout(rectangle{0, 0, miniWindowWidth, miniWindowHeight})
+	m.progressRect = m.rect.cut(rectCutDown, 4, 0)
+	m.rect.cut(rectCutLeft, miniPadding, 0)
+	m.rect.cut(rectCutRight, miniPadding, 0)
+	m.timeRect = m.rect.cut(rectCutRight, 90, miniPadding)
+	m.nameRect = m.rect.cut(rectCutUp, m.rect.remaining.height/2+4, 0)
+	m.phaseRect = m.rect.cut(rectCutUp, m.rect.remaining.height, 0)
+}
+
+func (m *miniWindow) enter() {
+	x, y := ebiten.WindowPosition()
+	m.restorePos = point{float64(x), float64(y)}
+	w, h := ebiten.WindowSize()
+	m.restoreSize = point{float64(w), float64(h)}
+	m.active = true
+	m.pressed = false
+	m.dragged = false
+
+	ebiten.SetWindowDecorated(false)
+	ebiten.SetWindowFloating(true)
+	ebiten.SetWindowResizable(false)
+	ebiten.SetWindowSizeLimits(-1, -1, -1, -1)
+	ebiten.SetWindowSize(m.windowSize())
+}
+
+// The strip keeps the same layout, only growing with the user scale
+func (m *miniWindow) windowSize() (int, int) {
+	return int(miniWindowWidth * userScale()), int(miniWindowHeight * userScale())
+}
+
+func (m *miniWindow) leave() {
+	m.active = false
+	ebiten.SetWindowFloating(false)
+	ebiten.SetWindowDecorated(true)
+	ebiten.SetWindowResizable(true)
+	setWindowSizeLimits()
+	ebiten.SetWindowSize(int(m.restoreSize[0]), int(m.restoreSize[1]))
+	ebiten.SetWindowPosition(int(m.restorePos[0]), int(m.restorePos[1]))
+}
+
+func (m *miniWindow) update(mPos point) {
+	const dragThreshold = 3
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		m.pressed = true
+		m.dragStart = mPos
+		m.dragged = false
+	}
+	if !m.pressed {
+		return
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		// The window moves along with the cursor,
+		// so the offset is always from the press
+		delta := mPos.sub(m.dragStart)
+		scale := userScale()
+		if m.dragged || delta[0]*delta[0]+delta[1]*delta[1] > dragThreshold*dragThreshold {
+			m.dragged = true
+			x, y := ebiten.WindowPosition()
+			ebiten.SetWindowPosition(x+int(delta[0]*scale), y+int(delta[1]*scale))
+		}
+	}
+	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		m.pressed = false
+		if !m.dragged {
+			m.leave()
+		}
+	}
+}
+
+func (m *miniWindow) draw(dst *ebiten.Image, t *task) {
+	drawRect(dst, m.rect.full, theme.Background2)
+	drawImageSlice(dst, m.rect.full, rectOutline, rectConstraint, theme.MutedText)
+
+	if t == nil {
+		drawTextCenter(dst, textOptions{
+			font: m.font, text: tr("No task running"), bounds: m.rect.full,
+			size: textSize, clr: theme.MutedText,
+		})
+		return
+	}
+
+	drawText(dst, textOptions{
+		font: m.font, text: t.name, pos: point{m.nameRect.x(), m.nameRect.y() + 6},
+		size: textSize, clr: theme.Text,
+	})
+	phase := tr("Stopwatch")
+	if len(t.phases) > 0 {
+		phase = tr(t.currentPhase().name)
+	}
+	if t.state == taskStatePaused {
+		phase = tr("{phase} (paused)", "{phase}", phase)
+	}
+	drawText(dst, textOptions{
+		font: m.font, text: phase, pos: point{m.phaseRect.x(), m.phaseRect.y()},
+		size: smallTextSize, clr: theme.MutedText,
+	})
+	clr := theme.Text
+	if t.overtime {
+		clr = theme.Flow
+	}
+	drawTextCenter(dst, textOptions{
+		font: m.font, text: t.getCurrentTime(), bounds: m.timeRect.remaining,
+		size: largeTextSize, clr: clr,
+	})
+
+	progress := m.progressRect.full
+	progress.width *= t.progress()
+	drawRect(dst, progress, rampColor(t, theme.Text))
+}
+
+// The first running task is shown, the selected one otherwise
+func (t *Todo) miniTask() *task {
+	for i := 0; i < t.tasks.count; i += 1 {
+		task := t.tasks.getTask(i)
+		if task.isInProgress() {
+			return task
+		}
+	}
+	return t.selected
+}
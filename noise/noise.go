@@ -0,0 +1,131 @@
+package noise
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+const (
+	White Color = iota
+	Pink
+	Brown
+	Rain
+	ColorCount
+)
+
+// 16 bits stereo
+const bytesPerFrame = 4
+
+type (
+	// Procedural noise streamed as signed 16 bits little endian
+	// stereo PCM. Reading never ends, so it can be fed directly
+	// to an audio player. The color can be changed while playing
+	Generator struct {
+		mutex sync.Mutex
+		color Color
+		rand  *rand.Rand
+
+		// Paul Kellet's pink noise filter
+		pink [7]float64
+		// Leaky integrator
+		brown float64
+
+		// Rain is a low passed noise bed with
+		// random decaying drops on top
+		rainBed   float64
+		lastWhite float64
+		drop      float64
+	}
+
+	Color int
+)
+
+func NewGenerator(c Color, seed int64) *Generator {
+	return &Generator{
+		color: c,
+		rand:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (c Color) String() string {
+	switch c {
+	case White:
+		return "White"
+	case Pink:
+		return "Pink"
+	case Brown:
+		return "Brown"
+	case Rain:
+		return "Rain"
+	default:
+		return ""
+	}
+}
+
+func (g *Generator) SetColor(c Color) {
+	g.mutex.Lock()
+	g.color = c
+	g.mutex.Unlock()
+}
+
+func (g *Generator) Color() Color {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.color
+}
+
+// Next sample, in the [-1, 1] range
+func (g *Generator) Next() float64 {
+	white := g.rand.Float64()*2 - 1
+	var v float64
+	switch g.color {
+	case White:
+		v = white * 0.5
+
+	case Pink:
+		p := &g.pink
+		p[0] = 0.99886*p[0] + white*0.0555179
+		p[1] = 0.99332*p[1] + white*0.0750759
+		p[2] = 0.96900*p[2] + white*0.1538520
+		p[3] = 0.86650*p[3] + white*0.3104856
+		p[4] = 0.55000*p[4] + white*0.5329522
+		p[5] = -0.7616*p[5] - white*0.0168980
+		v = (p[0] + p[1] + p[2] + p[3] + p[4] + p[5] + p[6] + white*0.5362) * 0.11
+		p[6] = white * 0.115926
+
+	case Brown:
+		g.brown = (g.brown + 0.02*white) / 1.02
+		v = g.brown * 3.5
+
+	case Rain:
+		const dropChance = 0.0008
+		const dropDecay = 0.995
+		g.rainBed += 0.15 * (white - g.rainBed)
+		if g.rand.Float64() < dropChance {
+			g.drop = 0.3 + g.rand.Float64()*0.5
+		}
+		g.drop *= dropDecay
+		// Crude high pass so the drops sound crisp
+		crisp := white - g.lastWhite
+		g.lastWhite = white
+		v = g.rainBed*1.2 + crisp*g.drop*0.5
+	}
+	return math.Max(-1, math.Min(v, 1))
+}
+
+func (g *Generator) Read(p []byte) (int, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	frames := len(p) / bytesPerFrame
+	for i := 0; i < frames; i += 1 {
+		s := int16(g.Next() * math.MaxInt16)
+		lo, hi := byte(s), byte(s>>8)
+		p[i*bytesPerFrame] = lo
+		p[i*bytesPerFrame+1] = hi
+		p[i*bytesPerFrame+2] = lo
+		p[i*bytesPerFrame+3] = hi
+	}
+	return frames * bytesPerFrame, nil
+}
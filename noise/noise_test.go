@@ -0,0 +1,115 @@
+package noise
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+const sampleCount = 1 << 16
+
+func samples(c Color) []float64 {
+	g := NewGenerator(c, 1)
+	s := make([]float64, sampleCount)
+	for i := range s {
+		s[i] = g.Next()
+	}
+	return s
+}
+
+// Lag-1 autocorrelation, close to 0 for a flat spectrum
+// and closer to 1 the more the energy sits in the low frequencies
+func autocorrelation(s []float64) float64 {
+	var mean float64
+	for _, v := range s {
+		mean += v
+	}
+	mean /= float64(len(s))
+	var num, den float64
+	for i := range s {
+		d := s[i] - mean
+		den += d * d
+		if i > 0 {
+			num += d * (s[i-1] - mean)
+		}
+	}
+	return num / den
+}
+
+// Energy ratio between the lower and upper halves of the spectrum,
+// measured with a first difference acting as a crude high-pass
+func lowHighRatio(s []float64) float64 {
+	var total, high float64
+	for i := 1; i < len(s); i += 1 {
+		total += s[i] * s[i]
+		d := s[i] - s[i-1]
+		high += d * d
+	}
+	return total / high
+}
+
+func TestRange(t *testing.T) {
+	for c := Color(0); c < ColorCount; c += 1 {
+		for i, v := range samples(c) {
+			if v < -1 || v > 1 || math.IsNaN(v) {
+				t.Fatalf("%s: sample %d out of range: %f", c, i, v)
+			}
+		}
+	}
+}
+
+func TestWhiteIsFlat(t *testing.T) {
+	if r := autocorrelation(samples(White)); math.Abs(r) > 0.02 {
+		t.Errorf("white noise autocorrelation = %f, want ~0", r)
+	}
+}
+
+func TestSpectralSlope(t *testing.T) {
+	white := lowHighRatio(samples(White))
+	pink := lowHighRatio(samples(Pink))
+	brown := lowHighRatio(samples(Brown))
+	if !(white < pink && pink < brown) {
+		t.Errorf("low/high energy ratios not increasing: white %f, pink %f, brown %f", white, pink, brown)
+	}
+	if r := autocorrelation(samples(Pink)); r < 0.5 || r > 0.95 {
+		t.Errorf("pink noise autocorrelation = %f, want between white and brown", r)
+	}
+	if r := autocorrelation(samples(Brown)); r < 0.95 {
+		t.Errorf("brown noise autocorrelation = %f, want close to 1", r)
+	}
+}
+
+func TestRainIsFiltered(t *testing.T) {
+	if r := autocorrelation(samples(Rain)); r < 0.1 {
+		t.Errorf("rain autocorrelation = %f, want filtered noise", r)
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	a, b := samples(Brown), samples(Brown)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("same seed gave different samples at %d", i)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	g := NewGenerator(Pink, 1)
+	// Partial frames are not written
+	p := make([]byte, 4*100+3)
+	n, err := g.Read(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4*100 {
+		t.Fatalf("Read returned %d bytes, want %d", n, 4*100)
+	}
+	for i := 0; i < n; i += 4 {
+		l := int16(binary.LittleEndian.Uint16(p[i:]))
+		r := int16(binary.LittleEndian.Uint16(p[i+2:]))
+		if l != r {
+			t.Fatalf("frame %d: channels differ %d != %d", i/4, l, r)
+		}
+	}
+}
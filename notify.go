@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	notifyWorkEnd notifyEvent = iota
+	notifyRestEnd
+	notifyTaskDone
+	notifyEventCount
+)
+
+type (
+	notifyEvent int
+
+	notification struct {
+		event   notifyEvent
+		title   string
+		message string
+	}
+
+	// Where the notifications end up, every enabled
+	// sink receives all of them
+	notifySink interface {
+		notify(n notification) error
+	}
+
+	notifier struct {
+		sinks   []notifySink
+		toasts  *toastQueue
+		logFile *os.File
+		err     error
+		// Kept apart, the settings failing don't hide the notifications failing
+		settingsFailed bool
+	}
+
+	toastSink struct {
+		toasts *toastQueue
+	}
+
+	// Runs a desktop command such as notify-send,
+	// {title} and {message} being replaced in its arguments
+	commandSink struct {
+		command []string
+	}
+
+	// Writes one line per notification to a file or stdout
+	writerSink struct {
+		w io.Writer
+	}
+)
+
+var notifyTitles = [notifyEventCount]string{
+	notifyWorkEnd:  "Time for a break",
+	notifyRestEnd:  "Break is over",
+	notifyTaskDone: "Goal completed",
+}
+
+func (n *notifier) init(toasts *toastQueue) {
+	n.toasts = toasts
+	AddSignalListener(todoTaskWorkEnded, n)
+	AddSignalListener(todoTaskRestEnded, n)
+	AddSignalListener(todoTaskCompleted, n)
+	AddSignalListener(todoTaskWarning, n)
+	AddSignalListener(todoSettingsChanged, n)
+	AddSignalListener(todoSettingsFailed, n)
+	n.reload()
+}
+
+func (n *notifier) reload() {
+	s := &userSettings.Notify
+	if n.logFile != nil {
+		n.logFile.Close()
+		n.logFile = nil
+	}
+
+	n.sinks = n.sinks[:0]
+	n.err = nil
+	if s.Toast {
+		n.sinks = append(n.sinks, &toastSink{toasts: n.toasts})
+	}
+	if s.Desktop {
+		n.sinks = append(n.sinks, &commandSink{command: strings.Fields(s.Command)})
+	}
+	if s.Log {
+		var w io.Writer = os.Stdout
+		if s.LogFile != "" {
+			f, err := os.OpenFile(s.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				n.fail(err)
+			} else {
+				n.logFile = f
+				w = f
+			}
+		}
+		n.sinks = append(n.sinks, &writerSink{w: w})
+	}
+}
+
+func (n *notifier) send(event notifyEvent, t *task) {
+	msg := notification{
+		event:   event,
+		title:   tr(notifyTitles[event]),
+		message: expandTemplate(userSettings.Notify.Templates.get(event), t),
+	}
+	for _, s := range n.sinks {
+		if err := s.notify(msg); err != nil {
+			n.fail(err)
+		}
+	}
+}
+
+// Only the first failure is shown, until the settings change
+func (n *notifier) fail(err error) {
+	if n.err != nil {
+		return
+	}
+	n.err = err
+	n.toasts.push(tr("Notifications {error}", "{error}", err.Error()))
+}
+
+// The settings that can't be read or saved, shown only once
+// as every change would fail the same way
+func (n *notifier) failSettings(err string) {
+	if n.settingsFailed {
+		return
+	}
+	n.settingsFailed = true
+	n.toasts.push(tr("Settings {error}", "{error}", err))
+}
+
+func (n *notifier) OnSignal(s Signal) {
+	switch s.Kind {
+	case todoTaskWorkEnded:
+		n.send(notifyWorkEnd, s.Value.(*task))
+	case todoTaskRestEnded:
+		n.send(notifyRestEnd, s.Value.(*task))
+	case todoTaskCompleted:
+		n.send(notifyTaskDone, s.Value.(*task))
+	case todoTaskWarning:
+		// Only meant for the user looking at the app
+		if userSettings.WarningToast {
+			n.toasts.push(expandTemplate(userSettings.Notify.Templates.Warning, s.Value.(*task)))
+		}
+	case todoSettingsChanged:
+		n.reload()
+	case todoSettingsFailed:
+		n.failSettings(string(s.Value.(SignalString)))
+	}
+}
+
+// Replaces the {task}, {phase}, {sessions}, {worked} and {left}
+// placeholders with the values of the task. The default templates
+// are translated, the ones changed by the user being kept as is
+func expandTemplate(template string, t *task) string {
+	phase := ""
+	if len(t.phases) > 0 {
+		phase = tr(t.currentPhase().name)
+	}
+	r := strings.NewReplacer(
+		"{task}", t.name,
+		"{phase}", phase,
+		"{sessions}", strconv.Itoa(t.sessionCompleted)+"/"+strconv.Itoa(t.sessionRequired),
+		"{worked}", formatDuration(t.workedTime),
+		"{left}", formatTimeLeft(t.timer.total()),
+	)
+	return r.Replace(tr(template))
+}
+
+func (s *toastSink) notify(n notification) error {
+	s.toasts.push(n.message)
+	return nil
+}
+
+func (s *commandSink) notify(n notification) error {
+	if len(s.command) == 0 {
+		return nil
+	}
+	args := make([]string, len(s.command)-1)
+	r := strings.NewReplacer("{title}", n.title, "{message}", n.message)
+	for i, arg := range s.command[1:] {
+		args[i] = r.Replace(arg)
+	}
+	cmd := exec.Command(s.command[0], args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Only reaping the process, the result doesn't matter
+	go cmd.Wait()
+	return nil
+}
+
+func (s *writerSink) notify(n notification) error {
+	_, err := fmt.Fprintf(s.w, "%s %s: %s\n", time.Now().Format(time.RFC3339), n.title, n.message)
+	return err
+}
+
+func formatTimeLeft(s seconds) string {
+	switch {
+	case s < 60:
+		return trn("{n} seconds", int(s))
+	default:
+		return trn("{n} minutes", int(s)/60)
+	}
+}
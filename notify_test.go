@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func newTestTask() *task {
+	t := &task{name: "Write", sessionRequired: 4, sessionCompleted: 1, sessionLength: 25, restLength: 5}
+	t.init()
+	t.workedTime = 25 * 60
+	return t
+}
+
+func toastTexts(q *toastQueue) []string {
+	texts := []string{}
+	for i := 0; i < q.count; i += 1 {
+		texts = append(texts, q.items[i].text)
+	}
+	return texts
+}
+
+func TestNotifyTemplates(t *testing.T) {
+	saved := userSettings
+	defer func() { userSettings = saved }()
+	useDefaultLocale()
+	userSettings = defaultSettings()
+	userSettings.Notify.Templates.RestEnd = "{task} {phase} {sessions} {worked}"
+
+	var buf bytes.Buffer
+	n := notifier{toasts: new(toastQueue), sinks: []notifySink{&writerSink{w: &buf}}}
+	tk := newTestTask()
+	cases := []struct {
+		kind  SignalKind
+		title string
+		text  string
+	}{
+		{todoTaskWorkEnded, "Time for a break", "Write: work done, time to rest"},
+		{todoTaskRestEnded, "Break is over", "Write Work 1/4 25m"},
+		{todoTaskCompleted, "Goal completed", "Write completed (1/4 sessions, 25m)"},
+	}
+	for _, c := range cases {
+		buf.Reset()
+		n.OnSignal(Signal{Kind: c.kind, Value: tk})
+		if want := " " + c.title + ": " + c.text + "\n"; !strings.HasSuffix(buf.String(), want) {
+			t.Errorf("signal %d wrote %q, want it ending with %q", c.kind, buf.String(), want)
+		}
+	}
+}
+
+func TestNotifySinkSettings(t *testing.T) {
+	saved := userSettings
+	defer func() { userSettings = saved }()
+	useDefaultLocale()
+	userSettings = defaultSettings()
+	logFile := filepath.Join(t.TempDir(), "notify.log")
+	userSettings.Notify.LogFile = logFile
+
+	toasts := new(toastQueue)
+	n := notifier{toasts: toasts}
+	defer func() {
+		if n.logFile != nil {
+			n.logFile.Close()
+		}
+	}()
+	tk := newTestTask()
+	send := func(toast, log bool) {
+		userSettings.Notify.Toast = toast
+		userSettings.Notify.Log = log
+		n.OnSignal(Signal{Kind: todoSettingsChanged, Value: SignalNoArgs})
+		n.OnSignal(Signal{Kind: todoTaskWorkEnded, Value: tk})
+	}
+	lines := func() int {
+		data, _ := os.ReadFile(logFile)
+		return strings.Count(string(data), "\n")
+	}
+
+	send(false, true)
+	if lines() != 1 || toasts.count != 0 {
+		t.Errorf("log only: %d lines and %d toasts, want 1 and 0", lines(), toasts.count)
+	}
+	send(true, false)
+	if lines() != 1 || toasts.count != 1 {
+		t.Errorf("toast only: %d lines and %d toasts, want 1 and 1", lines(), toasts.count)
+	}
+	send(false, false)
+	if lines() != 1 || toasts.count != 1 {
+		t.Errorf("none: %d lines and %d toasts, want 1 and 1", lines(), toasts.count)
+	}
+	if n.err != nil {
+		t.Errorf("unexpected error %v", n.err)
+	}
+
+	// The warning is only shown in the app, when enabled
+	userSettings.Notify.Log = true
+	n.OnSignal(Signal{Kind: todoSettingsChanged, Value: SignalNoArgs})
+	userSettings.WarningToast = false
+	n.OnSignal(Signal{Kind: todoTaskWarning, Value: tk})
+	if lines() != 1 || toasts.count != 1 {
+		t.Errorf("warning off: %d lines and %d toasts, want 1 and 1", lines(), toasts.count)
+	}
+	userSettings.WarningToast = true
+	n.OnSignal(Signal{Kind: todoTaskWarning, Value: tk})
+	if lines() != 1 || toasts.count != 2 {
+		t.Errorf("warning on: %d lines and %d toasts, want 1 and 2", lines(), toasts.count)
+	}
+}
+
+func TestNotifyFailure(t *testing.T) {
+	saved := userSettings
+	defer func() { userSettings = saved }()
+	useDefaultLocale()
+	userSettings = defaultSettings()
+
+	toasts := new(toastQueue)
+	n := notifier{toasts: toasts, sinks: []notifySink{&writerSink{w: failingWriter{}}}}
+	tk := newTestTask()
+	n.OnSignal(Signal{Kind: todoTaskWorkEnded, Value: tk})
+	n.OnSignal(Signal{Kind: todoTaskRestEnded, Value: tk})
+	want := []string{"Notifications disk full"}
+	if got := toastTexts(toasts); len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("toasts = %q, want %q", got, want)
+	}
+
+	// Reported again once the settings changed
+	userSettings.Notify.Toast = false
+	n.OnSignal(Signal{Kind: todoSettingsChanged, Value: SignalNoArgs})
+	if n.err != nil {
+		t.Fatalf("error %v kept after the settings changed", n.err)
+	}
+	n.sinks = append(n.sinks, &writerSink{w: failingWriter{}})
+	n.OnSignal(Signal{Kind: todoTaskCompleted, Value: tk})
+	if toasts.count != 2 {
+		t.Errorf("%d toasts, want the failure reported again", toasts.count)
+	}
+}
+
+func TestNotifySettingsFailure(t *testing.T) {
+	saved := userSettings
+	defer func() { userSettings = saved }()
+	useDefaultLocale()
+	userSettings = defaultSettings()
+
+	toasts := new(toastQueue)
+	n := notifier{toasts: toasts, sinks: []notifySink{&writerSink{w: failingWriter{}}}}
+	n.OnSignal(Signal{Kind: todoSettingsFailed, Value: SignalString("read-only")})
+	n.OnSignal(Signal{Kind: todoSettingsFailed, Value: SignalString("read-only")})
+	// Shown along the notifications failing
+	n.OnSignal(Signal{Kind: todoTaskWorkEnded, Value: newTestTask()})
+	want := []string{"Settings read-only", "Notifications disk full"}
+	if got := toastTexts(toasts); !reflect.DeepEqual(got, want) {
+		t.Errorf("toasts = %q, want %q", got, want)
+	}
+}
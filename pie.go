@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	timerStyleDigits = iota
+	timerStylePie
+)
+
+// Segments used for a full turn, a disc
+// only uses the part it needs
+const pieSegments = 96
+
+var (
+	timerStyleNames = []string{"Digits", "Pie"}
+
+	// Sampling from the center of a bigger image avoids
+	// bleeding from the edges when drawing triangles
+	pieSrcImage *ebiten.Image
+)
+
+// Draws the part of a disc starting at 12 o'clock and
+// going clockwise, 1 being the full disc
+func drawPie(dst *ebiten.Image, center point, radius, fraction float64, clr Color) {
+	if fraction <= 0 {
+		return
+	}
+	if pieSrcImage == nil {
+		img := ebiten.NewImage(3, 3)
+		img.Fill(White)
+		pieSrcImage = img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+	}
+	fraction = math.Min(fraction, 1)
+	center = point{center[0] * uiScale, center[1] * uiScale}
+	radius *= uiScale
+	count := int(math.Ceil(pieSegments * fraction))
+	r, g, b, a := float32(clr[0])/255, float32(clr[1])/255, float32(clr[2])/255, float32(clr[3])/255
+	vertex := func(x, y float64) ebiten.Vertex {
+		return ebiten.Vertex{
+			DstX: float32(x), DstY: float32(y),
+			SrcX: 1, SrcY: 1,
+			ColorR: r, ColorG: g, ColorB: b, ColorA: a,
+		}
+	}
+
+	vertices := make([]ebiten.Vertex, 0, count+2)
+	indices := make([]uint16, 0, count*3)
+	vertices = append(vertices, vertex(center[0], center[1]))
+	for i := 0; i <= count; i += 1 {
+		angle := 2 * math.Pi * fraction * float64(i) / float64(count)
+		vertices = append(vertices, vertex(
+			center[0]+math.Sin(angle)*radius,
+			center[1]-math.Cos(angle)*radius,
+		))
+		if i > 0 {
+			indices = append(indices, 0, uint16(i), uint16(i+1))
+		}
+	}
+	dst.DrawTriangles(vertices, indices, pieSrcImage, nil)
+}
+
+// Time timer like display: the coloured part shrinks as the time
+// passes, the digits being drawn in the hole when given
+func drawTimerPie(dst *ebiten.Image, rect rectangle, remaining float64, clr, bg Color, digits string, size float64) {
+	const holeRatio = 0.6
+
+	radius := math.Min(rect.width, rect.height)/2 - 6
+	center := point{rect.x + rect.width/2, rect.y + rect.height/2}
+	track := clr
+	track[3] = 40
+	drawPie(dst, center, radius, 1, track)
+	drawPie(dst, center, radius, remaining, clr)
+	if digits != "" {
+		drawPie(dst, center, radius*holeRatio, 1, bg)
+		drawTextCenter(dst, textOptions{
+			font: &defaultFont, text: digits, bounds: rect,
+			size: size, clr: theme.Text,
+		})
+	}
+}
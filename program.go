@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	phaseWork phaseKind = iota
+	phaseRest
+)
+
+type (
+	// A task runs its phases one after the other,
+	// one full run of the program being a session
+	phase struct {
+		name   string
+		kind   phaseKind
+		length minute
+		icon   *ebiten.Image
+		// Points into the current theme
+		clr *Color
+	}
+
+	phaseKind int
+
+	programPreset struct {
+		name string
+		// Not a program, the task counts up instead
+		stopwatch bool
+		warmUp    minute
+		work      minute
+		rest      minute
+		repeat    int
+		review    minute
+		// The whole sequence of the programs from the settings,
+		// work and rest being the first of their kind
+		phases []customPhase
+	}
+)
+
+var programPresets = []programPreset{
+	{name: "Pomodoro", work: 25, rest: 5, repeat: 1},
+	{name: "52/17", work: 52, rest: 17, repeat: 1},
+	{name: "Ultradian", work: 90, rest: 20, repeat: 1},
+	{name: "Desktime", work: 112, rest: 26, repeat: 1},
+	{name: "Deep work", warmUp: 10, work: 25, rest: 5, repeat: 4, review: 20},
+	{name: "Stopwatch", stopwatch: true},
+}
+
+func newWorkPhase(length minute) phase {
+	return phase{name: "Work", kind: phaseWork, length: length, icon: timerWorkIcon, clr: &theme.Work}
+}
+
+func newRestPhase(length minute) phase {
+	return phase{name: "Rest", kind: phaseRest, length: length, icon: timerRestIcon, clr: &theme.Rest}
+}
+
+// The work and rest lengths are the one chosen by the user,
+// the other phases keep the length of the preset
+func (p programPreset) build(work, rest minute) []phase {
+	if p.stopwatch {
+		return nil
+	}
+	if len(p.phases) > 0 {
+		return p.buildCustom(work, rest)
+	}
+	phases := make([]phase, 0, p.repeat*2+2)
+	if p.warmUp > 0 {
+		phases = append(phases, phase{
+			name: "Warm-up", kind: phaseWork, length: p.warmUp,
+			icon: timerWarmUpIcon, clr: &theme.WarmUp,
+		})
+	}
+	for i := 0; i < p.repeat; i += 1 {
+		phases = append(phases, newWorkPhase(work), newRestPhase(rest))
+	}
+	if p.review > 0 {
+		phases = append(phases, phase{
+			name: "Review", kind: phaseWork, length: p.review,
+			icon: timerReviewIcon, clr: &theme.Review,
+		})
+	}
+	return phases
+}
+
+// Only the phases with the default work or rest length follow
+// the chosen ones, the others keeping theirs
+func (p programPreset) buildCustom(work, rest minute) []phase {
+	phases := make([]phase, 0, len(p.phases))
+	for _, spec := range p.phases {
+		switch {
+		case spec.Kind == "work" && minute(spec.Length) == p.work:
+			spec.Length = int(work)
+		case spec.Kind == "rest" && minute(spec.Length) == p.rest:
+			spec.Length = int(rest)
+		}
+		// Checked when loaded, the chosen lengths being in range too
+		ph, _ := newCustomPhase(spec)
+		phases = append(phases, ph)
+	}
+	return phases
+}
+
+// Adds the programs of the settings to the built-in ones,
+// the invalid ones being left out
+func loadPrograms() []error {
+	var errs []error
+	for i, c := range userSettings.Programs {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("Program %d", i+1)
+		}
+		preset := programPreset{name: name, repeat: 1, phases: c.Phases}
+		var err error
+		if len(c.Phases) == 0 {
+			err = fmt.Errorf("%s: no phases", name)
+		}
+		for j, spec := range c.Phases {
+			if _, err = newCustomPhase(spec); err != nil {
+				err = fmt.Errorf("%s: phase %d: %w", name, j+1, err)
+				break
+			}
+			switch {
+			case spec.Kind == "work" && preset.work == 0:
+				preset.work = minute(spec.Length)
+			case spec.Kind == "rest" && preset.rest == 0:
+				preset.rest = minute(spec.Length)
+			}
+		}
+		if err == nil && preset.work == 0 {
+			err = fmt.Errorf("%s: no work phase", name)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if preset.rest == 0 {
+			preset.rest = minSessionLength
+		}
+		programPresets = append(programPresets, preset)
+	}
+	return errs
+}
+
+func newCustomPhase(spec customPhase) (phase, error) {
+	length := minute(spec.Length)
+	if length < minSessionLength || length > maxSessionLength {
+		return phase{}, fmt.Errorf("length %d out of range", spec.Length)
+	}
+	var p phase
+	switch spec.Kind {
+	case "work":
+		p = newWorkPhase(length)
+	case "rest":
+		p = newRestPhase(length)
+	case "warmUp":
+		p = phase{name: "Warm-up", kind: phaseWork, length: length, icon: timerWarmUpIcon, clr: &theme.WarmUp}
+	case "review":
+		p = phase{name: "Review", kind: phaseWork, length: length, icon: timerReviewIcon, clr: &theme.Review}
+	default:
+		return phase{}, fmt.Errorf("unknown kind %q", spec.Kind)
+	}
+	if spec.Name != "" {
+		p.name = spec.Name
+	}
+	return p, nil
+}
+
+func (k phaseKind) state() taskState {
+	switch k {
+	case phaseRest:
+		return taskStateRest
+	default:
+		return taskStateWork
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const (
+	settingsDirName  = "todo"
+	settingsFileName = "settings.json"
+)
+
+const (
+	minUIScale = 50
+	maxUIScale = 200
+
+	minIdleMinutes    = 1
+	maxIdleMinutes    = 60
+	minWarningSeconds = 0
+	maxWarningSeconds = 600
+)
+
+type settings struct {
+	// Let the work timer run past zero until a break is taken
+	FlowMode bool `json:"flowMode"`
+	// Scale the rest length to the actual time spent working
+	FlowScaleRest bool `json:"flowScaleRest"`
+
+	// Pause the work sessions after a period without input
+	IdleDetection bool `json:"idleDetection"`
+	IdleMinutes   int  `json:"idleMinutes"`
+
+	Sound bool `json:"sound"`
+	// Warn this many seconds before the end of a phase, 0 to disable
+	WarningSeconds int `json:"warningSeconds"`
+	// How the warning is shown, the sound being the warning cue
+	WarningPulse bool        `json:"warningPulse"`
+	WarningToast bool        `json:"warningToast"`
+	WarningRamp  bool        `json:"warningRamp"`
+	Cues         cueSettings `json:"cues"`
+
+	// Modal screen during the rest phases, with one
+	// of the suggestions shown at a time
+	BreakScreen      bool     `json:"breakScreen"`
+	BreakSuggestions []string `json:"breakSuggestions"`
+
+	// Width of the task list, changed by dragging the separator
+	ListWidth int `json:"listWidth"`
+	// In percent, on top of the display scale
+	UIScale int `json:"uiScale"`
+	// One of the built-in themes or the name given in a theme file
+	Theme string `json:"theme"`
+	// Code of one of the catalogs, empty to follow the system
+	Language string `json:"language"`
+
+	// Digits or pie, the digits being optional with the pie
+	TimerStyle  int  `json:"timerStyle"`
+	TimerDigits bool `json:"timerDigits"`
+
+	// Ambient noise during work, 0 being off
+	Noise       int `json:"noise"`
+	NoiseVolume int `json:"noiseVolume"`
+
+	Notify notifySettings `json:"notify"`
+
+	// Added after the built-in programs
+	Programs []customProgram `json:"programs,omitempty"`
+}
+
+type (
+	cueSettings struct {
+		WorkEnd  cueSetting `json:"workEnd"`
+		RestEnd  cueSetting `json:"restEnd"`
+		TaskDone cueSetting `json:"taskDone"`
+		Warning  cueSetting `json:"warning"`
+	}
+
+	// Each sink can be enabled on its own
+	notifySettings struct {
+		Toast     bool            `json:"toast"`
+		Desktop   bool            `json:"desktop"`
+		Command   string          `json:"command"`
+		Log       bool            `json:"log"`
+		LogFile   string          `json:"logFile,omitempty"`
+		Templates notifyTemplates `json:"templates"`
+	}
+
+	notifyTemplates struct {
+		WorkEnd  string `json:"workEnd"`
+		RestEnd  string `json:"restEnd"`
+		TaskDone string `json:"taskDone"`
+		Warning  string `json:"warning"`
+	}
+
+	// Any sequence of phases, run once per session
+	customProgram struct {
+		Name   string        `json:"name"`
+		Phases []customPhase `json:"phases"`
+	}
+
+	// The name defaults to the one of the kind: work,
+	// rest, warmUp or review. The length is in minutes
+	customPhase struct {
+		Name   string `json:"name,omitempty"`
+		Kind   string `json:"kind"`
+		Length int    `json:"length"`
+	}
+
+	// An empty file means the built-in sound
+	cueSetting struct {
+		File   string `json:"file,omitempty"`
+		Volume int    `json:"volume"`
+		Muted  bool   `json:"muted"`
+	}
+)
+
+var userSettings = defaultSettings()
+
+func defaultSettings() settings {
+	return settings{
+		FlowMode:      false,
+		FlowScaleRest: true,
+		IdleDetection: true,
+		IdleMinutes:   5,
+
+		Sound:          true,
+		WarningSeconds: 120,
+		WarningPulse:   true,
+		WarningToast:   true,
+		WarningRamp:    true,
+		Cues: cueSettings{
+			WorkEnd:  cueSetting{Volume: 80},
+			RestEnd:  cueSetting{Volume: 80},
+			TaskDone: cueSetting{Volume: 80},
+			Warning:  cueSetting{Volume: 50},
+		},
+
+		BreakScreen: false,
+		BreakSuggestions: []string{
+			"Stand up and stretch",
+			"Drink some water",
+			"Look at something far away",
+			"Take a few deep breaths",
+			"Walk around for a bit",
+		},
+
+		ListWidth: 200,
+		UIScale:   100,
+		Theme:     "Dark",
+		Language:  "",
+
+		TimerStyle:  timerStyleDigits,
+		TimerDigits: true,
+
+		Noise:       0,
+		NoiseVolume: 40,
+
+		Notify: notifySettings{
+			Toast:   true,
+			Desktop: false,
+			Command: "notify-send {title} {message}",
+			Log:     false,
+			Templates: notifyTemplates{
+				WorkEnd:  "{task}: work done, time to rest",
+				RestEnd:  "{task}: break is over",
+				TaskDone: "{task} completed ({sessions} sessions, {worked})",
+				Warning:  "{left} left, wrap up your thought",
+			},
+		},
+	}
+}
+
+func (c *cueSettings) get(cue soundCue) *cueSetting {
+	switch cue {
+	case cueWorkEnd:
+		return &c.WorkEnd
+	case cueRestEnd:
+		return &c.RestEnd
+	case cueTaskDone:
+		return &c.TaskDone
+	default:
+		return &c.Warning
+	}
+}
+
+func (t *notifyTemplates) get(event notifyEvent) string {
+	switch event {
+	case notifyWorkEnd:
+		return t.WorkEnd
+	case notifyRestEnd:
+		return t.RestEnd
+	default:
+		return t.TaskDone
+	}
+}
+
+func settingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, settingsDirName, settingsFileName), nil
+}
+
+// Missing settings file is not an error, the defaults are kept
+func loadSettings() error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	s := defaultSettings()
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.Noise < 0 || s.Noise >= len(noiseNames) {
+		s.Noise = 0
+	}
+	if s.TimerStyle < 0 || s.TimerStyle >= len(timerStyleNames) {
+		s.TimerStyle = timerStyleDigits
+	}
+	if s.UIScale < minUIScale || s.UIScale > maxUIScale {
+		s.UIScale = 100
+	}
+	if s.IdleMinutes < minIdleMinutes || s.IdleMinutes > maxIdleMinutes {
+		s.IdleMinutes = 5
+	}
+	if s.WarningSeconds < minWarningSeconds || s.WarningSeconds > maxWarningSeconds {
+		s.WarningSeconds = 120
+	}
+	userSettings = s
+	return nil
+}
+
+// Saved from anywhere in the app, a failure
+// being shown by the notifier
+func storeSettings() {
+	if err := saveSettings(); err != nil {
+		FireSignal(todoSettingsFailed, SignalString(err.Error()))
+	}
+}
+
+func saveSettings() error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(userSettings, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
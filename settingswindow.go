@@ -0,0 +1,231 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"todo/ui"
+)
+
+const (
+	settingRowHeight    = 30
+	settingControlWidth = 130
+	settingScrollSpeed  = 20
+)
+
+type (
+	settingsWindow struct {
+		active   bool
+		dirty    bool
+		canvas   *ebiten.Image
+		position point
+
+		rect rectLayout
+
+		widgets ui.Root
+		title   ui.Label
+		list    ui.List
+		// Translated when drawn, for the language to change live
+		labels  []settingLabel
+		choices []settingChoice
+
+		font          *Font
+		rectOutline   *ebiten.Image
+		outlineConstr constraint
+	}
+
+	settingLabel struct {
+		key  string
+		text *string
+	}
+
+	// The options can change while the app runs, like the themes
+	settingChoice struct {
+		stepper *ui.Stepper
+		options *[]string
+	}
+)
+
+func (s *settingsWindow) init(font *Font, outline *ebiten.Image) {
+	const settingsWindowPadding = 10
+	const settingsWindowMargin = 20
+
+	AddSignalListener(todoSettingsBtnPressed, s)
+	s.font = font
+	s.rectOutline = outline
+	s.outlineConstr = constraint{2, 2, 2, 2}
+
+	s.rect = newRectLayout(rectangle{
+		x:      0,
+		y:      0,
+		width:  400,
+		height: 400,
+	})
+	s.rect.cut(rectCutUp, settingsWindowPadding, 0)
+	s.rect.cut(rectCutDown, settingsWindowPadding, 0)
+
+	titleRect := s.rect.cut(rectCutUp, textSize, 15)
+	titleRect.cut(rectCutLeft, settingsWindowPadding, 0)
+	titleRect.cut(rectCutRight, settingsWindowPadding, 0)
+
+	listRect := s.rect.cut(rectCutUp, s.rect.remaining.height, 0)
+	listRect.cut(rectCutLeft, settingsWindowMargin, 0)
+	listRect.cut(rectCutRight, settingsWindowMargin, 0)
+
+	s.title = ui.Label{Size: ui.SizeLarge, Align: ui.AlignCenter}
+	s.title.SetBounds(uiRect(titleRect.remaining))
+	s.list = ui.List{RowHeight: settingRowHeight, Separators: true, ScrollStep: settingScrollSpeed}
+	s.list.SetBounds(uiRect(listRect.remaining))
+	s.widgets.Add(&s.title)
+	s.widgets.Add(&s.list)
+	s.addLabel("Settings", &s.title.Text)
+
+	s.addToggle("Flow mode", &userSettings.FlowMode)
+	s.addToggle("Scale rest to flow time", &userSettings.FlowScaleRest)
+	s.addToggle("Idle detection", &userSettings.IdleDetection)
+	s.addNumber("Idle after (min)", &userSettings.IdleMinutes, minIdleMinutes, maxIdleMinutes, 1)
+	s.addChoice("Language", &languageChoice, &languageNames)
+	s.addChoice("Theme", &themeChoice, &themeNames)
+	s.addNumber("UI scale (%)", &userSettings.UIScale, minUIScale, maxUIScale, 25)
+	s.addToggle("Break screen", &userSettings.BreakScreen)
+	s.addChoice("Timer style", &userSettings.TimerStyle, &timerStyleNames)
+	s.addToggle("Digits on the pie", &userSettings.TimerDigits)
+	s.addToggle("Sound", &userSettings.Sound)
+	s.addNumber("Warn before end (s)", &userSettings.WarningSeconds, minWarningSeconds, maxWarningSeconds, 10)
+	s.addToggle("Pulse on warning", &userSettings.WarningPulse)
+	s.addToggle("Show warning message", &userSettings.WarningToast)
+	s.addToggle("Highlight last minute", &userSettings.WarningRamp)
+	// Whole labels, easier to translate
+	cueLabels := [cueCount][2]string{
+		{"Work end volume", "Work end muted"},
+		{"Rest end volume", "Rest end muted"},
+		{"Task done volume", "Task done muted"},
+		{"Warning volume", "Warning muted"},
+	}
+	for cue := soundCue(0); cue < cueCount; cue += 1 {
+		c := userSettings.Cues.get(cue)
+		s.addNumber(cueLabels[cue][0], &c.Volume, 0, 100, 10)
+		s.addToggle(cueLabels[cue][1], &c.Muted)
+	}
+	s.addToggle("In-app notifications", &userSettings.Notify.Toast)
+	s.addToggle("Desktop notifications", &userSettings.Notify.Desktop)
+	s.addToggle("Log notifications", &userSettings.Notify.Log)
+
+}
+
+// Keeps the window centered when the app is resized,
+// the canvas following the UI scale
+func (s *settingsWindow) layout() {
+	s.position = point{
+		screenBounds.width/2 - s.rect.full.width/2,
+		screenBounds.height/2 - s.rect.full.height/2,
+	}
+	s.dirty = true
+	s.canvas = resizeCanvas(s.canvas, s.rect.full)
+}
+
+func (s *settingsWindow) addLabel(key string, text *string) {
+	s.labels = append(s.labels, settingLabel{key: key, text: text})
+}
+
+func (s *settingsWindow) addToggle(label string, value *bool) {
+	row := &ui.Checkbox{
+		Value:    value,
+		BoxSize:  textSize - itemPadding,
+		OnChange: func(bool) { s.changed() },
+	}
+	s.addLabel(label, &row.Label)
+	s.list.Add(row)
+}
+
+// The label on the left, the control on the right
+func (s *settingsWindow) addStepperRow(label string, stepper *ui.Stepper) {
+	text := &ui.Label{}
+	s.addLabel(label, &text.Text)
+	stepper.Size = ui.SizeSmall
+	stepper.ArrowWidth = s.font.GlyphAdvance('>', textSize) + 3
+	stepper.OnChange = func(int) { s.changed() }
+
+	row := &ui.Group{}
+	row.Add(text)
+	row.Add(stepper)
+	row.Layout = func(r ui.Rect) {
+		layout := newRectLayout(fromUIRect(r))
+		control := layout.cut(rectCutRight, settingControlWidth, 0).full
+		text.SetBounds(uiRect(layout.remaining))
+		stepper.SetBounds(uiRect(rectangle{control.x, control.y + 4, control.width, control.height - 8}))
+	}
+	s.list.Add(row)
+}
+
+func (s *settingsWindow) addNumber(label string, value *int, min, max, step int) {
+	s.addStepperRow(label, &ui.Stepper{Value: value, Min: min, Max: max, Step: step})
+}
+
+func (s *settingsWindow) addChoice(label string, value *int, options *[]string) {
+	stepper := &ui.Stepper{
+		Value: value, Max: len(*options) - 1, Wrap: true,
+		Format: func(v int) string {
+			if v >= 0 && v < len(*options) {
+				return tr((*options)[v])
+			}
+			return ""
+		},
+	}
+	s.choices = append(s.choices, settingChoice{stepper: stepper, options: options})
+	s.addStepperRow(label, stepper)
+}
+
+func (s *settingsWindow) changed() {
+	s.dirty = true
+	storeSettings()
+	FireSignal(todoSettingsChanged, SignalNoArgs)
+}
+
+func (s *settingsWindow) update(mPos point, mLeft bool) {
+	if s.active {
+		relPos := mPos.sub(s.position)
+		if !s.rect.full.boundCheck(relPos) && mLeft {
+			s.active = false
+			FireSignal(todoSettingsWindowClosed, SignalNoArgs)
+			return
+		}
+		if s.widgets.Update(newUIInput(mPos, mLeft, s.position, s.font)) {
+			s.dirty = true
+		}
+	}
+}
+
+func (s *settingsWindow) draw(dst *ebiten.Image) {
+	if s.active {
+		drawModalBackground(dst)
+
+		rect := s.rect.full.addPoint(s.position)
+		drawRect(dst, rect, theme.Background1)
+		drawImageSlice(dst, rect, s.rectOutline, s.outlineConstr, theme.Text)
+
+		if s.dirty {
+			s.redraw()
+		}
+		drawCanvas(dst, s.canvas, s.position)
+	}
+}
+
+func (s *settingsWindow) redraw() {
+	s.canvas.Clear()
+	for _, l := range s.labels {
+		*l.text = tr(l.key)
+	}
+	for _, c := range s.choices {
+		c.stepper.Max = len(*c.options) - 1
+	}
+	s.widgets.Draw(widgetPainter{dst: s.canvas, font: s.font})
+	s.dirty = false
+}
+
+func (s *settingsWindow) OnSignal(sig Signal) {
+	switch sig.Kind {
+	case todoSettingsBtnPressed:
+		s.active = true
+		s.dirty = true
+	}
+}
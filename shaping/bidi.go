@@ -0,0 +1,242 @@
+package shaping
+
+import "golang.org/x/text/unicode/bidi"
+
+// The embedding levels of a single line of text, following the
+// Unicode bidi algorithm without the explicit embeddings, which
+// task names don't really need
+func resolveLevels(runes []rune) []int {
+	types := make([]bidi.Class, len(runes))
+	for i, r := range runes {
+		props, _ := bidi.LookupRune(r)
+		types[i] = props.Class()
+		switch types[i] {
+		case bidi.LRE, bidi.RLE, bidi.LRO, bidi.RLO, bidi.PDF:
+			types[i] = bidi.BN
+		case bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI:
+			types[i] = bidi.ON
+		}
+	}
+
+	// P2, P3: the paragraph takes the direction of its first strong character
+	base := 0
+	for _, t := range types {
+		if t == bidi.L {
+			break
+		}
+		if t == bidi.R || t == bidi.AL {
+			base = 1
+			break
+		}
+	}
+	sos := bidi.L
+	if base == 1 {
+		sos = bidi.R
+	}
+
+	// W1: the marks take the type of what they follow
+	for i, t := range types {
+		if t == bidi.NSM {
+			if i == 0 {
+				types[i] = sos
+			} else {
+				types[i] = types[i-1]
+			}
+		}
+	}
+	// W2, W3: numbers after Arabic letters are Arabic numbers
+	lastStrong := sos
+	for i, t := range types {
+		switch t {
+		case bidi.L, bidi.R, bidi.AL:
+			lastStrong = t
+		case bidi.EN:
+			if lastStrong == bidi.AL {
+				types[i] = bidi.AN
+			}
+		}
+	}
+	for i, t := range types {
+		if t == bidi.AL {
+			types[i] = bidi.R
+		}
+	}
+	// W4: a single separator between two numbers of the same kind
+	for i := 1; i < len(types)-1; i += 1 {
+		before, after := types[i-1], types[i+1]
+		switch {
+		case types[i] == bidi.ES && before == bidi.EN && after == bidi.EN:
+			types[i] = bidi.EN
+		case types[i] == bidi.CS && before == after && (before == bidi.EN || before == bidi.AN):
+			types[i] = before
+		}
+	}
+	// W5: the terminators next to European numbers
+	for i := 0; i < len(types); {
+		if types[i] != bidi.ET {
+			i += 1
+			continue
+		}
+		end := i
+		for end < len(types) && types[end] == bidi.ET {
+			end += 1
+		}
+		if (i > 0 && types[i-1] == bidi.EN) || (end < len(types) && types[end] == bidi.EN) {
+			for j := i; j < end; j += 1 {
+				types[j] = bidi.EN
+			}
+		}
+		i = end
+	}
+	// W6, W7: the other separators become neutral, and numbers
+	// in left to right text are left to right
+	lastStrong = sos
+	for i, t := range types {
+		switch t {
+		case bidi.ES, bidi.ET, bidi.CS:
+			types[i] = bidi.ON
+		case bidi.L, bidi.R:
+			lastStrong = t
+		case bidi.EN:
+			if lastStrong == bidi.L {
+				types[i] = bidi.L
+			}
+		}
+	}
+
+	resolveBrackets(runes, types, sos)
+
+	// N1, N2: the neutrals between two of the same direction take it,
+	// the numbers counting as right to left, the base direction otherwise
+	strong := func(t bidi.Class) bidi.Class {
+		if t == bidi.EN || t == bidi.AN {
+			return bidi.R
+		}
+		return t
+	}
+	for i := 0; i < len(types); {
+		if !isNeutral(types[i]) {
+			i += 1
+			continue
+		}
+		end := i
+		for end < len(types) && isNeutral(types[end]) {
+			end += 1
+		}
+		before, after := sos, sos
+		if i > 0 {
+			before = strong(types[i-1])
+		}
+		if end < len(types) {
+			after = strong(types[end])
+		}
+		resolved := sos
+		if before == after {
+			resolved = before
+		}
+		for j := i; j < end; j += 1 {
+			types[j] = resolved
+		}
+		i = end
+	}
+
+	// I1, I2
+	levels := make([]int, len(types))
+	for i, t := range types {
+		switch {
+		case base == 0 && t == bidi.R:
+			levels[i] = 1
+		case base == 0 && (t == bidi.AN || t == bidi.EN):
+			levels[i] = 2
+		case base == 1 && (t == bidi.L || t == bidi.AN || t == bidi.EN):
+			levels[i] = 2
+		default:
+			levels[i] = base
+		}
+	}
+
+	// L1: the trailing spaces go back to the base level
+	for i := len(runes) - 1; i >= 0; i -= 1 {
+		props, _ := bidi.LookupRune(runes[i])
+		if c := props.Class(); c != bidi.WS && c != bidi.S && c != bidi.B && c != bidi.BN {
+			break
+		}
+		levels[i] = base
+	}
+	return levels
+}
+
+var closingBrackets = map[rune]rune{')': '(', ']': '[', '}': '{'}
+
+// N0: the two brackets of a pair take the direction of what they
+// enclose, the one of the text before them when it isn't clear
+func resolveBrackets(runes []rune, types []bidi.Class, sos bidi.Class) {
+	direction := func(t bidi.Class) bidi.Class {
+		switch t {
+		case bidi.L:
+			return bidi.L
+		case bidi.R, bidi.EN, bidi.AN:
+			return bidi.R
+		}
+		return bidi.ON
+	}
+
+	openings := []int{}
+	for i, r := range runes {
+		if types[i] != bidi.ON {
+			continue
+		}
+		if r == '(' || r == '[' || r == '{' {
+			openings = append(openings, i)
+			continue
+		}
+		opening, isClosing := closingBrackets[r]
+		if !isClosing {
+			continue
+		}
+		for j := len(openings) - 1; j >= 0; j -= 1 {
+			if runes[openings[j]] != opening {
+				continue
+			}
+			start := openings[j]
+			openings = openings[:j]
+
+			resolved := bidi.ON
+			for k := start + 1; k < i; k += 1 {
+				d := direction(types[k])
+				if d == sos {
+					resolved = sos
+					break
+				}
+				if d != bidi.ON {
+					resolved = d
+				}
+			}
+			if resolved != bidi.ON && resolved != sos {
+				before := sos
+				for k := start - 1; k >= 0; k -= 1 {
+					if d := direction(types[k]); d != bidi.ON {
+						before = d
+						break
+					}
+				}
+				if before != resolved {
+					resolved = sos
+				}
+			}
+			if resolved != bidi.ON {
+				types[start] = resolved
+				types[i] = resolved
+			}
+			break
+		}
+	}
+}
+
+func isNeutral(t bidi.Class) bool {
+	switch t {
+	case bidi.B, bidi.S, bidi.WS, bidi.ON, bidi.BN:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+// Package shaping turns text into the glyphs to draw from left to
+// right: the Unicode bidi algorithm puts the characters in visual
+// order and the Arabic letters are joined with their presentation forms
+package shaping
+
+type Layout struct {
+	// What gets drawn, from left to right
+	Visual []rune
+	// For every rune of the text, the visual rune it ended up in
+	// and whether it was laid out from right to left
+	Positions []int
+	RTL       []bool
+}
+
+// Only the scripts from Hebrew on need any work
+func needsShaping(runes []rune) bool {
+	for _, r := range runes {
+		if r >= 0x0590 && r < 0x0900 || r >= 0xFB1D && r <= 0xFEFC {
+			return true
+		}
+	}
+	return false
+}
+
+func Shape(text string) Layout {
+	runes := []rune(text)
+	if !needsShaping(runes) {
+		return unshaped(runes)
+	}
+
+	levels := resolveLevels(runes)
+	shaped, owners := shapeArabic(runes)
+	shapedLevels := make([]int, len(shaped))
+	for i := len(runes) - 1; i >= 0; i -= 1 {
+		// The lam of a ligature comes first, its level is kept
+		shapedLevels[owners[i]] = levels[i]
+	}
+
+	// Reversing from the highest level down, each time every
+	// sequence at that level or above
+	order := make([]int, len(shaped))
+	highest := 0
+	for i := range order {
+		order[i] = i
+		if shapedLevels[i] > highest {
+			highest = shapedLevels[i]
+		}
+	}
+	for level := highest; level > 0; level -= 1 {
+		for start := 0; start < len(order); {
+			if shapedLevels[order[start]] < level {
+				start += 1
+				continue
+			}
+			end := start
+			for end < len(order) && shapedLevels[order[end]] >= level {
+				end += 1
+			}
+			for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+				order[i], order[j] = order[j], order[i]
+			}
+			start = end
+		}
+	}
+
+	l := Layout{
+		Visual:    make([]rune, len(shaped)),
+		Positions: make([]int, len(runes)),
+		RTL:       make([]bool, len(runes)),
+	}
+	visualIndex := make([]int, len(shaped))
+	for v, i := range order {
+		l.Visual[v] = shaped[i]
+		if shapedLevels[i]%2 == 1 {
+			l.Visual[v] = mirror(shaped[i])
+		}
+		visualIndex[i] = v
+	}
+	for i := range runes {
+		l.Positions[i] = visualIndex[owners[i]]
+		l.RTL[i] = levels[i]%2 == 1
+	}
+	return l
+}
+
+// Left to right as it is
+func unshaped(runes []rune) Layout {
+	l := Layout{
+		Visual:    runes,
+		Positions: make([]int, len(runes)),
+		RTL:       make([]bool, len(runes)),
+	}
+	for i := range runes {
+		l.Positions[i] = i
+	}
+	return l
+}
+
+// The brackets point the other way in right to left text
+func mirror(r rune) rune {
+	switch r {
+	case '(':
+		return ')'
+	case ')':
+		return '('
+	case '[':
+		return ']'
+	case ']':
+		return '['
+	case '{':
+		return '}'
+	case '}':
+		return '{'
+	case '<':
+		return '>'
+	case '>':
+		return '<'
+	}
+	return r
+}
+
+// Where a cursor placed before the logical rune goes, as a
+// visual index, the last position being the end of the text
+func (l Layout) Caret(index int) int {
+	if len(l.Positions) == 0 {
+		return 0
+	}
+	if index >= len(l.Positions) {
+		last := len(l.Positions) - 1
+		if l.RTL[last] {
+			return l.Positions[last]
+		}
+		return l.Positions[last] + 1
+	}
+	if l.RTL[index] {
+		return l.Positions[index] + 1
+	}
+	return l.Positions[index]
+}
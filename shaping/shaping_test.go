@@ -0,0 +1,100 @@
+package shaping
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShape(t *testing.T) {
+	cases := []struct {
+		name      string
+		text      string
+		visual    string
+		positions []int
+	}{
+		{"ltr", "abc", "abc", []int{0, 1, 2}},
+		{"ltr with numbers", "a 12", "a 12", []int{0, 1, 2, 3}},
+		{"rtl", "שלום", "םולש", []int{3, 2, 1, 0}},
+		{"rtl with spaces", "אב גד", "דג בא", []int{4, 3, 2, 1, 0}},
+		// The numbers keep reading from left to right
+		{"hebrew with numbers", "אב 12 גד", "דג 12 בא", []int{7, 6, 5, 3, 4, 2, 1, 0}},
+		{"arabic with numbers", "ب 12", "12 ﺏ", []int{3, 2, 0, 1}},
+		{"ltr then rtl", "abc 12 אב", "abc 12 בא", []int{0, 1, 2, 3, 4, 5, 6, 8, 7}},
+		{"rtl then ltr", "אב abc", "abc בא", []int{5, 4, 3, 0, 1, 2}},
+		{"mirrored brackets", "א(ב)", "(ב)א", []int{3, 2, 1, 0}},
+	}
+	for _, c := range cases {
+		l := Shape(c.text)
+		if got := string(l.Visual); got != c.visual {
+			t.Errorf("%s: Visual = %q, want %q", c.name, got, c.visual)
+		}
+		if !reflect.DeepEqual(l.Positions, c.positions) {
+			t.Errorf("%s: Positions = %v, want %v", c.name, l.Positions, c.positions)
+		}
+	}
+}
+
+func TestArabicForms(t *testing.T) {
+	cases := []struct {
+		name   string
+		text   string
+		shaped []rune
+		owners []int
+	}{
+		{"isolated", "ب", []rune{0xFE8F}, []int{0}},
+		{"initial and final", "بب", []rune{0xFE91, 0xFE90}, []int{0, 1}},
+		{"medial", "ببب", []rune{0xFE91, 0xFE92, 0xFE90}, []int{0, 1, 2}},
+		// Dal never joins the next letter
+		{"non joining", "دب", []rune{0xFEA9, 0xFE8F}, []int{0, 1}},
+		{"after non joining", "بدب", []rune{0xFE91, 0xFEAA, 0xFE8F}, []int{0, 1, 2}},
+		{"space breaks", "ب ب", []rune{0xFE8F, ' ', 0xFE8F}, []int{0, 1, 2}},
+		// The fatha is skipped over
+		{"transparent mark", "بَب", []rune{0xFE91, 0x064E, 0xFE90}, []int{0, 1, 2}},
+		{"lam alef isolated", "لا", []rune{0xFEFB}, []int{0, 0}},
+		{"lam alef final", "بلا", []rune{0xFE91, 0xFEFC}, []int{0, 1, 1}},
+		{"lam alef hamza", "لأ", []rune{0xFEF7}, []int{0, 0}},
+		{"lam alef madda final", "بلآ", []rune{0xFE91, 0xFEF6}, []int{0, 1, 1}},
+		// The alef of the ligature doesn't join what comes next
+		{"after lam alef", "لاب", []rune{0xFEFB, 0xFE8F}, []int{0, 0, 1}},
+		{"lam without alef", "لب", []rune{0xFEDF, 0xFE90}, []int{0, 1}},
+	}
+	for _, c := range cases {
+		shaped, owners := shapeArabic([]rune(c.text))
+		if !reflect.DeepEqual(shaped, c.shaped) {
+			t.Errorf("%s: shaped = %X, want %X", c.name, shaped, c.shaped)
+		}
+		if !reflect.DeepEqual(owners, c.owners) {
+			t.Errorf("%s: owners = %v, want %v", c.name, owners, c.owners)
+		}
+	}
+}
+
+func TestShapeLigature(t *testing.T) {
+	l := Shape("بلا")
+	if got, want := l.Visual, []rune{0xFEFC, 0xFE91}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Visual = %X, want %X", got, want)
+	}
+	// Both letters of the ligature end up in the same glyph
+	if got, want := l.Positions, []int{1, 0, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Positions = %v, want %v", got, want)
+	}
+}
+
+func TestCaret(t *testing.T) {
+	l := Shape("ab אב")
+	cases := []struct {
+		index, caret int
+	}{
+		{0, 0},
+		{2, 2},
+		// Before the first Hebrew letter is on its right
+		{3, 5},
+		{4, 4},
+		{5, 3},
+	}
+	for _, c := range cases {
+		if got := l.Caret(c.index); got != c.caret {
+			t.Errorf("Caret(%d) = %d, want %d", c.index, got, c.caret)
+		}
+	}
+}
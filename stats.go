@@ -0,0 +1,273 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	minSimilarWordSize = 3
+)
+
+type (
+	// Aggregated estimate against actual values
+	// for a group of tasks (a tag, a day...)
+	estimateStats struct {
+		label            string
+		taskCount        int
+		sessionsRequired int
+		sessionsDone     int
+		estimatedTime    seconds
+		workedTime       seconds
+		breaksTaken      int
+		breaksSkipped    int
+	}
+
+	estimateSuggestion struct {
+		sessions int
+		samples  int
+	}
+)
+
+// Tags are written directly in the task name, e.g. "Write report #work"
+func parseTags(name string) []string {
+	var tags []string
+	for _, word := range strings.Fields(name) {
+		if len(word) > 1 && word[0] == '#' {
+			tags = append(tags, strings.ToLower(word[1:]))
+		}
+	}
+	return tags
+}
+
+func (t task) estimatedTime() seconds {
+	if t.kind == taskKindStopwatch {
+		return seconds(t.target) * 60
+	}
+	return seconds(t.sessionRequired) * seconds(t.sessionWorkLength()) * 60
+}
+
+func (t task) extraSessions() int {
+	if t.sessionCompleted > t.sessionRequired {
+		return t.sessionCompleted - t.sessionRequired
+	}
+	return 0
+}
+
+// Ratio of the actual sessions over the estimated ones.
+// Above 1 means the task was underestimated
+func (t task) estimateRatio() float64 {
+	if t.kind == taskKindStopwatch {
+		if t.target == 0 {
+			return 0
+		}
+		return float64(t.workedTime) / float64(t.estimatedTime())
+	}
+	if t.sessionRequired == 0 {
+		return 0
+	}
+	return float64(t.sessionCompleted) / float64(t.sessionRequired)
+}
+
+func (s *estimateStats) add(t *task) {
+	s.taskCount += 1
+	s.sessionsRequired += t.sessionRequired
+	s.sessionsDone += t.sessionCompleted
+	s.estimatedTime += t.estimatedTime()
+	s.workedTime += t.workedTime
+	s.breaksTaken += t.breaksTaken
+	s.breaksSkipped += t.breaksSkipped
+}
+
+func (s estimateStats) ratio() float64 {
+	if s.sessionsRequired == 0 {
+		return 0
+	}
+	return float64(s.sessionsDone) / float64(s.sessionsRequired)
+}
+
+// How close the estimates were, 1 being perfect.
+// Over and under estimation are penalized the same way
+func (s estimateStats) accuracy() float64 {
+	r := s.ratio()
+	if r == 0 {
+		return 0
+	}
+	return 1 - math.Min(math.Abs(1-r), 1)
+}
+
+func overallStats(tasks []task) estimateStats {
+	result := estimateStats{label: tr("All tasks")}
+	for i := range tasks {
+		result.add(&tasks[i])
+	}
+	return result
+}
+
+func tagStats(tasks []task) []estimateStats {
+	byTag := make(map[string]*estimateStats)
+	for i := range tasks {
+		t := &tasks[i]
+		tags := t.tags
+		if len(tags) == 0 {
+			tags = []string{""}
+		}
+		for _, tag := range tags {
+			s, exist := byTag[tag]
+			if !exist {
+				label := "#" + tag
+				if tag == "" {
+					label = tr("Untagged")
+				}
+				s = &estimateStats{label: label}
+				byTag[tag] = s
+			}
+			s.add(t)
+		}
+	}
+
+	result := make([]estimateStats, 0, len(byTag))
+	for _, s := range byTag {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].label < result[j].label
+	})
+	return result
+}
+
+// Groups the tasks by the day they were archived, oldest first
+func dailyStats(tasks []task) []estimateStats {
+	type day struct {
+		date  time.Time
+		stats estimateStats
+	}
+	var days []day
+	for i := range tasks {
+		t := &tasks[i]
+		y, m, d := t.archivedAt.Date()
+		date := time.Date(y, m, d, 0, 0, 0, 0, t.archivedAt.Location())
+
+		found := false
+		for j := range days {
+			if days[j].date.Equal(date) {
+				days[j].stats.add(t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			days = append(days, day{
+				date:  date,
+				stats: estimateStats{label: formatDate(date)},
+			})
+			days[len(days)-1].stats.add(t)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].date.Before(days[j].date)
+	})
+
+	result := make([]estimateStats, len(days))
+	for i := range days {
+		result[i] = days[i].stats
+	}
+	return result
+}
+
+// Looks for past tasks sharing a tag or a meaningful word
+// with the given name and averages the sessions they actually took
+func suggestEstimate(archive []task, name string) (s estimateSuggestion) {
+	tags := parseTags(name)
+	words := nameWords(name)
+	if len(tags) == 0 && len(words) == 0 {
+		return
+	}
+
+	total := 0
+	for i := range archive {
+		t := &archive[i]
+		if t.sessionCompleted == 0 || !isTaskSimilar(t, tags, words) {
+			continue
+		}
+		total += t.sessionCompleted
+		s.samples += 1
+	}
+	if s.samples > 0 {
+		s.sessions = int(math.Round(float64(total) / float64(s.samples)))
+		if s.sessions < minSessionCount {
+			s.sessions = minSessionCount
+		}
+	}
+	return
+}
+
+func isTaskSimilar(t *task, tags, words []string) bool {
+	for _, tag := range tags {
+		for _, other := range t.tags {
+			if tag == other {
+				return true
+			}
+		}
+	}
+	for _, word := range nameWords(t.name) {
+		for _, other := range words {
+			if word == other {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func nameWords(name string) []string {
+	var words []string
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '#'
+	})
+	for _, f := range fields {
+		if f[0] == '#' || len([]rune(f)) < minSimilarWordSize {
+			continue
+		}
+		words = append(words, f)
+	}
+	return words
+}
+
+func formatDuration(s seconds) string {
+	m := int(s) / 60
+	if m < 60 {
+		return strconv.Itoa(m) + "m"
+	}
+	rem := m % 60
+	str := strconv.Itoa(m/60) + "h"
+	if rem < 10 {
+		str += "0"
+	}
+	return str + strconv.Itoa(rem)
+}
+
+// Clock-like display, hours only shown when needed
+func formatClock(s seconds) string {
+	pad := func(n int) string {
+		if n < 10 {
+			return "0" + strconv.Itoa(n)
+		}
+		return strconv.Itoa(n)
+	}
+	h := int(s) / 3600
+	m := (int(s) % 3600) / 60
+	sec := int(s) % 60
+	if h > 0 {
+		return strconv.Itoa(h) + ":" + pad(m) + ":" + pad(sec)
+	}
+	return pad(m) + ":" + pad(sec)
+}
+
+func formatPercent(v float64) string {
+	return strconv.Itoa(int(math.Round(v*100))) + "%"
+}
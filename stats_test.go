@@ -0,0 +1,132 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// An archived task as the statistics see it, 25 minutes per session
+func newArchivedTask(name string, required, completed int, archivedAt time.Time) task {
+	t := task{name: name, sessionRequired: required, sessionCompleted: completed, sessionLength: 25, restLength: 5}
+	t.init()
+	t.workedTime = seconds(completed) * 25 * 60
+	t.archivedAt = archivedAt
+	return t
+}
+
+func statsLabels(stats []estimateStats) []string {
+	labels := []string{}
+	for _, s := range stats {
+		labels = append(labels, s.label)
+	}
+	return labels
+}
+
+func TestTagStats(test *testing.T) {
+	useDefaultLocale()
+	if got := tagStats(nil); len(got) != 0 {
+		test.Errorf("%d groups without tasks, want none", len(got))
+	}
+
+	now := time.Now()
+	tasks := []task{
+		newArchivedTask("Report #work", 2, 3, now),
+		newArchivedTask("Slides #work", 4, 4, now),
+	}
+	stats := tagStats(tasks)
+	if len(stats) != 1 {
+		test.Fatalf("groups = %v, want only #work", statsLabels(stats))
+	}
+	s := stats[0]
+	if s.label != "#work" || s.taskCount != 2 || s.sessionsRequired != 6 || s.sessionsDone != 7 {
+		test.Errorf("stats = %+v, want 2 tasks, 6 sessions planned and 7 done", s)
+	}
+	if s.estimatedTime != 6*25*60 || s.workedTime != 7*25*60 {
+		test.Errorf("estimated %d and worked %d, want %d and %d", s.estimatedTime, s.workedTime, 6*25*60, 7*25*60)
+	}
+
+	// A task counts for each of its tags, the untagged ones going together
+	tasks = append(tasks,
+		newArchivedTask("Review #work #code", 1, 1, now),
+		newArchivedTask("Groceries", 1, 1, now),
+	)
+	stats = tagStats(tasks)
+	want := []string{"#code", "#work", "Untagged"}
+	if got := statsLabels(stats); !reflect.DeepEqual(got, want) {
+		test.Fatalf("groups = %v, want %v", got, want)
+	}
+	counts := []int{1, 3, 1}
+	for i, s := range stats {
+		if s.taskCount != counts[i] {
+			test.Errorf("%s has %d tasks, want %d", s.label, s.taskCount, counts[i])
+		}
+	}
+}
+
+func TestDailyStats(test *testing.T) {
+	useDefaultLocale()
+	if got := dailyStats(nil); len(got) != 0 {
+		test.Errorf("%d days without tasks, want none", len(got))
+	}
+
+	monday := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.Local)
+	tuesday := monday.AddDate(0, 0, 1)
+	tasks := []task{
+		newArchivedTask("b", 2, 2, tuesday),
+		newArchivedTask("a", 1, 2, monday),
+		// Later the same day
+		newArchivedTask("c", 3, 1, monday.Add(12*time.Hour)),
+	}
+	stats := dailyStats(tasks)
+	want := []string{formatDate(monday), formatDate(tuesday)}
+	if got := statsLabels(stats); !reflect.DeepEqual(got, want) {
+		test.Fatalf("days = %v, want %v", got, want)
+	}
+	if s := stats[0]; s.taskCount != 2 || s.sessionsRequired != 4 || s.sessionsDone != 3 {
+		test.Errorf("monday = %+v, want 2 tasks, 4 sessions planned and 3 done", s)
+	}
+	if s := stats[1]; s.taskCount != 1 || s.ratio() != 1 {
+		test.Errorf("tuesday = %+v, want 1 task done as planned", s)
+	}
+}
+
+func TestSuggestEstimate(test *testing.T) {
+	useDefaultLocale()
+	now := time.Now()
+	archive := []task{
+		newArchivedTask("Report #work", 2, 2, now),
+		newArchivedTask("Slides #work", 2, 3, now),
+		newArchivedTask("Budget spreadsheet", 4, 4, now),
+		newArchivedTask("Budget review", 1, 5, now),
+		newArchivedTask("Budget draft", 1, 5, now),
+		// Never worked on, it says nothing about the time needed
+		newArchivedTask("Gym #work", 3, 0, now),
+	}
+
+	cases := []struct {
+		archive []task
+		name    string
+		want    estimateSuggestion
+	}{
+		{nil, "Report #work", estimateSuggestion{}},
+		{archive, "", estimateSuggestion{}},
+		// Too short to be a meaningful word
+		{archive, "Do it", estimateSuggestion{}},
+		{archive, "Nothing alike", estimateSuggestion{}},
+		{archive[:1], "Minutes #work", estimateSuggestion{sessions: 2, samples: 1}},
+		// 2.5 rounded up
+		{archive, "Plan #work", estimateSuggestion{sessions: 3, samples: 2}},
+		// 4.67 rounded up
+		{archive, "budget", estimateSuggestion{sessions: 5, samples: 3}},
+		// 2.33 rounded down
+		{[]task{archive[0], archive[0], archive[1]}, "#WORK", estimateSuggestion{sessions: 2, samples: 3}},
+		// A tag or a word is enough, 3.5 rounded up
+		{archive[:4], "Budget #work", estimateSuggestion{sessions: 4, samples: 4}},
+	}
+	for _, c := range cases {
+		if got := suggestEstimate(c.archive, c.name); got != c.want {
+			test.Errorf("suggestEstimate(%d tasks, %q) = %+v, want %+v", len(c.archive), c.name, got, c.want)
+		}
+	}
+}
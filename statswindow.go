@@ -0,0 +1,178 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type (
+	statsWindow struct {
+		active   bool
+		dirty    bool
+		canvas   *ebiten.Image
+		position point
+
+		rect        rectLayout
+		titleRect   rectLayout
+		summaryRect rectLayout
+		tagRect     rectLayout
+		dailyRect   rectLayout
+
+		font          *Font
+		rectOutline   *ebiten.Image
+		outlineConstr constraint
+	}
+)
+
+func (s *statsWindow) init(font *Font, outline *ebiten.Image) {
+	AddSignalListener(todoStatsBtnPressed, s)
+
+	s.active = false
+	s.font = font
+	s.rectOutline = outline
+	s.outlineConstr = constraint{2, 2, 2, 2}
+}
+
+// Same as the archive, growing with the app
+func (s *statsWindow) layout() {
+	const statsWindowPadding = 10
+	const statsWindowMargin = 30
+
+	s.rect = newRectLayout(rectangle{
+		x:      0,
+		y:      0,
+		width:  screenBounds.width - 200,
+		height: screenBounds.height - 100,
+	})
+	s.position = point{
+		screenBounds.width/2 - s.rect.full.width/2,
+		screenBounds.height/2 - s.rect.full.height/2,
+	}
+	s.rect.cut(rectCutUp, statsWindowPadding, 0)
+	s.rect.cut(rectCutDown, statsWindowPadding, 0)
+
+	s.titleRect = s.rect.cut(rectCutUp, textSize, 15)
+	s.titleRect.cut(rectCutLeft, statsWindowPadding, 0)
+	s.titleRect.cut(rectCutRight, statsWindowPadding, 0)
+
+	s.summaryRect = s.rect.cut(rectCutUp, itemHeight*2, statsWindowPadding)
+	s.summaryRect.cut(rectCutLeft, statsWindowMargin, 0)
+	s.summaryRect.cut(rectCutRight, statsWindowMargin, 0)
+
+	s.rect.cut(rectCutLeft, statsWindowMargin, 0)
+	s.rect.cut(rectCutRight, statsWindowMargin, 0)
+	columnWidth := (s.rect.remaining.width - statsWindowPadding) / 2
+	s.tagRect = s.rect.cut(rectCutLeft, columnWidth, statsWindowPadding)
+	s.dailyRect = s.rect.cut(rectCutLeft, columnWidth, 0)
+
+	s.dirty = true
+	s.canvas = resizeCanvas(s.canvas, s.rect.full)
+}
+
+func (s *statsWindow) update(mPos point, mLeft bool) {
+	if s.active {
+		relPos := mPos.sub(s.position)
+		if !s.rect.full.boundCheck(relPos) && mLeft {
+			s.active = false
+			FireSignal(todoStatsWindowClosed, SignalNoArgs)
+			return
+		}
+	}
+}
+
+func (s *statsWindow) draw(dst *ebiten.Image, archivedTasks []task) {
+	if s.active {
+		drawModalBackground(dst)
+
+		rect := s.rect.full.addPoint(s.position)
+		drawRect(dst, rect, theme.Background1)
+		drawImageSlice(dst, rect, s.rectOutline, s.outlineConstr, theme.Text)
+
+		for _, r := range []rectangle{s.tagRect.full, s.dailyRect.full} {
+			rect = r.addPoint(s.position)
+			drawRect(dst, rect, theme.Background2)
+			drawImageSlice(dst, rect, s.rectOutline, s.outlineConstr, theme.Separator)
+		}
+
+		if s.dirty {
+			s.redraw(archivedTasks)
+		}
+		drawCanvas(dst, s.canvas, s.position)
+	}
+}
+
+func (s *statsWindow) redraw(archivedTasks []task) {
+	s.canvas.Clear()
+
+	drawTextCenter(s.canvas, textOptions{
+		font: s.font, text: tr("Statistics"), bounds: s.titleRect.remaining,
+		size: largeTextSize, clr: theme.Text,
+	})
+
+	overall := overallStats(archivedTasks)
+	summary := s.summaryRect.remaining
+	drawText(s.canvas, textOptions{
+		font: s.font,
+		text: trn("{n} archived tasks", overall.taskCount) + ", " +
+			trn("{done}/{n} sessions", overall.sessionsRequired, "{done}", strconv.Itoa(overall.sessionsDone)),
+		pos:  point{summary.x, summary.y},
+		size: textSize, clr: theme.Text,
+	})
+	drawText(s.canvas, textOptions{
+		font: s.font,
+		text: tr("Estimated {estimated}, worked {worked}, accuracy {accuracy}",
+			"{estimated}", formatDuration(overall.estimatedTime),
+			"{worked}", formatDuration(overall.workedTime),
+			"{accuracy}", formatPercent(overall.accuracy()),
+		) + ", " + trn("{skipped}/{n} breaks skipped", overall.breaksTaken, "{skipped}", strconv.Itoa(overall.breaksSkipped)),
+		pos:  point{summary.x, summary.y + itemHeight},
+		size: smallTextSize, clr: theme.MutedText,
+	})
+
+	s.drawColumn(tr("By tag"), s.tagRect.remaining, tagStats(archivedTasks))
+	s.drawColumn(tr("Over time"), s.dailyRect.remaining, dailyStats(archivedTasks))
+	s.dirty = false
+}
+
+func (s *statsWindow) drawColumn(title string, bounds rectangle, stats []estimateStats) {
+	const ratioWidth = 60
+	const sessionsWidth = 60
+
+	drawText(s.canvas, textOptions{
+		font: s.font, text: title, pos: point{bounds.x + itemPadding, bounds.y + itemPadding},
+		size: textSize, clr: theme.Text,
+	})
+	y := bounds.y + itemHeight
+	for _, stat := range stats {
+		if y+itemHeight > bounds.y+bounds.height {
+			break
+		}
+		row := newRectLayout(rectangle{bounds.x, y, bounds.width, itemHeight})
+		ratioRect := row.cut(rectCutRight, ratioWidth, 0)
+		sessionsRect := row.cut(rectCutRight, sessionsWidth, 0)
+
+		drawText(s.canvas, textOptions{
+			font: s.font, text: stat.label, pos: point{row.x() + itemPadding, row.y() + itemPadding},
+			size: smallTextSize, clr: theme.Text,
+		})
+		drawTextCenter(s.canvas, textOptions{
+			font: s.font, text: strconv.Itoa(stat.sessionsDone) + "/" + strconv.Itoa(stat.sessionsRequired),
+			bounds: sessionsRect.remaining, size: smallTextSize, clr: theme.MutedText,
+		})
+		drawTextCenter(s.canvas, textOptions{
+			font: s.font, text: formatPercent(stat.accuracy()),
+			bounds: ratioRect.remaining, size: smallTextSize, clr: theme.Text,
+		})
+		drawRect(s.canvas, rectangle{bounds.x, y + itemHeight, bounds.width, 1}, theme.Separator)
+		y += itemHeight
+	}
+}
+
+func (s *statsWindow) OnSignal(sig Signal) {
+	switch sig.Kind {
+	case todoStatsBtnPressed:
+		s.active = true
+		s.dirty = true
+	}
+}
@@ -1,10 +1,20 @@
 package main
 
-import "math"
+import (
+	"math"
+	"time"
+)
 
 const (
 	minSessionLength minute = 1
+	maxSessionLength minute = 999
 	minSessionCount  int    = 1
+	maxSessionCount  int    = 999
+)
+
+const (
+	taskKindTimer taskKind = iota
+	taskKindStopwatch
 )
 
 const (
@@ -20,7 +30,10 @@ var (
 		// idle -> idle
 		nil,
 		// work -> idle
-		nil,
+		func(t *task) {
+			// The program can end with a work phase
+			t.completeSession()
+		},
 		// rest -> idle
 		func(t *task) {
 			t.completeSession()
@@ -32,13 +45,16 @@ var (
 		// idle -> work
 		func(t *task) {
 			// This means that the task was not previously paused
-			t.timer.setDuration(t.sessionLength, 0)
-			t.timer.start()
+			t.enterPhase()
 		},
 		// work -> work
-		nil,
+		func(t *task) {
+			t.enterPhase()
+		},
 		// rest -> work
-		nil,
+		func(t *task) {
+			t.enterPhase()
+		},
 		// paused -> work
 		func(t *task) {
 			t.timer.start()
@@ -47,15 +63,16 @@ var (
 		//
 		// idle -> rest
 		func(t *task) {
-			t.timer.start()
+			t.enterPhase()
 		},
 		// work -> rest
 		func(t *task) {
-			t.timer.setDuration(t.restLength, 0)
-			t.timer.start()
+			t.enterPhase()
 		},
 		// rest -> rest
-		nil,
+		func(t *task) {
+			t.enterPhase()
+		},
 		// paused -> rest
 		func(t *task) {
 			t.timer.start()
@@ -80,7 +97,9 @@ var (
 type (
 	task struct {
 		name          string
+		notes         string
 		id            int
+		kind          taskKind
 		done          bool
 		state         taskState
 		previousState taskState
@@ -91,6 +110,35 @@ type (
 		sessionLength    minute
 		restLength       minute
 
+		// The sequence of phases making a session.
+		// Defaults to a single work and rest phase
+		programName string
+		phases      []phase
+		phaseIndex  int
+		phaseTotal  seconds
+
+		// Stopwatch tasks only, zero when there is no target
+		target minute
+
+		// Actual time spent working, used to compare
+		// against the initial estimate
+		workedTime seconds
+		idleTime   seconds
+		tags       []string
+		createdAt  time.Time
+		archivedAt time.Time
+
+		// Flow mode: the work timer keeps counting up
+		// past the session length until a break is taken
+		overtime bool
+		flowTime seconds
+		// Extra time recorded over all the sessions
+		totalFlowTime seconds
+
+		// Every rest phase started, and the ones cut short
+		breaksTaken   int
+		breaksSkipped int
+
 		timer    timer
 		workText [5]rune
 		restText [5]rune
@@ -98,6 +146,8 @@ type (
 
 	taskState int
 
+	taskKind int
+
 	taskBuffer struct {
 		items []task
 		count int
@@ -107,17 +157,44 @@ type (
 
 func (t *task) init() {
 	t.transitions = taskTransitionTable
-	t.timer.setDuration(t.sessionLength, 0)
+	t.tags = parseTags(t.name)
+	if t.kind == taskKindStopwatch {
+		t.timer.setCountUp()
+		return
+	}
+	if len(t.phases) == 0 {
+		t.phases = []phase{newWorkPhase(t.sessionLength), newRestPhase(t.restLength)}
+	}
+	t.phaseIndex = 0
+	t.resetTimer()
 }
 
 func (t *task) update() {
 	if t.timer.running {
-		if finished := t.timer.advance(); finished {
+		ticked, finished := t.timer.advance()
+		if ticked && t.state == taskStateWork {
+			t.workedTime += 1
+			if t.overtime {
+				t.flowTime += 1
+			}
+			if t.kind == taskKindStopwatch && t.target > 0 && t.workedTime >= seconds(t.target)*60 && !t.done {
+				t.done = true
+				FireSignal(todoTaskCompleted, t)
+			}
+		}
+		if ticked && t.isWarningTime() {
+			FireSignal(todoTaskWarning, t)
+		}
+		if finished {
 			switch t.state {
 			case taskStateWork:
-				t.changeState(taskStateRest)
+				if userSettings.FlowMode {
+					t.startOvertime()
+				} else {
+					t.nextPhase()
+				}
 			case taskStateRest:
-				t.changeState(taskStateIdle)
+				t.nextPhase()
 			default:
 				// invalid state
 			}
@@ -129,8 +206,65 @@ func (t *task) completeSession() {
 	t.sessionCompleted += 1
 	if t.sessionCompleted == t.sessionRequired {
 		t.done = true
+		FireSignal(todoTaskCompleted, t)
+	}
+	t.endOvertime()
+	t.phaseIndex = 0
+	t.resetTimer()
+}
+
+func (t *task) currentPhase() *phase {
+	return &t.phases[t.phaseIndex]
+}
+
+// Moves to the next phase of the program, going back
+// to idle and completing the session after the last one
+func (t *task) nextPhase() {
+	switch {
+	case t.state == taskStateWork && !t.overtime:
+		// Already notified when the overtime started
+		FireSignal(todoTaskWorkEnded, t)
+	case t.state == taskStateRest:
+		FireSignal(todoTaskRestEnded, t)
+	}
+	if t.phaseIndex+1 >= len(t.phases) {
+		t.changeState(taskStateIdle)
+		return
 	}
-	t.timer.setDuration(t.sessionLength, 0)
+	t.phaseIndex += 1
+	t.changeState(t.currentPhase().kind.state())
+}
+
+func (t *task) enterPhase() {
+	if t.kind == taskKindStopwatch {
+		// Keeps accumulating from where it was stopped
+		t.timer.start()
+		return
+	}
+	m, s := t.phaseDuration()
+	t.phaseTotal = seconds(m)*60 + s
+	t.timer.setDuration(m, s)
+	t.timer.start()
+	t.endOvertime()
+	if t.currentPhase().kind == phaseRest {
+		t.breaksTaken += 1
+		FireSignal(todoTaskRestStarted, t)
+	}
+}
+
+func (t *task) resetTimer() {
+	length := t.phases[t.phaseIndex].length
+	t.phaseTotal = seconds(length) * 60
+	t.timer.setDuration(length, 0)
+}
+
+// The end of the current phase is near
+func (t *task) isWarningTime() bool {
+	warning := seconds(userSettings.WarningSeconds)
+	if warning == 0 || t.timer.countUp || t.phaseTotal <= warning {
+		return false
+	}
+	return (t.state == taskStateWork || t.state == taskStateRest) && t.timer.total() == warning
 }
 
 func (t *task) startWork() {
@@ -142,8 +276,10 @@ func (t *task) startWork() {
 		case taskStateRest:
 			t.changeState(taskStateRest)
 		}
-	} else {
+	} else if t.kind == taskKindStopwatch {
 		t.changeState(taskStateWork)
+	} else {
+		t.changeState(t.currentPhase().kind.state())
 	}
 }
 
@@ -151,22 +287,80 @@ func (t *task) stopWork() {
 	t.changeState(taskStatePaused)
 }
 
+func (t *task) startOvertime() {
+	FireSignal(todoTaskWorkEnded, t)
+	t.overtime = true
+	t.flowTime = 0
+	t.timer.setCountUp()
+	t.timer.start()
+}
+
+func (t *task) endOvertime() {
+	t.totalFlowTime += t.flowTime
+	t.overtime = false
+	t.flowTime = 0
+}
+
+// Only valid when the work timer is running past zero
+func (t *task) takeBreak() {
+	if t.overtime && t.state == taskStateWork {
+		t.nextPhase()
+	}
+}
+
+// Ends the rest early, the break being recorded as skipped
+func (t *task) skipBreak() {
+	if t.state == taskStateRest {
+		t.breaksSkipped += 1
+		t.nextPhase()
+	}
+}
+
+// Flowtime-style, a rest following a work phase that ran past
+// zero is scaled proportionally to the time actually spent working
+func (t *task) phaseDuration() (minute, seconds) {
+	p := t.currentPhase()
+	if p.kind != phaseRest || t.flowTime == 0 || !userSettings.FlowScaleRest || t.phaseIndex == 0 {
+		return p.length, 0
+	}
+	planned := seconds(t.phases[t.phaseIndex-1].length) * 60
+	if planned == 0 {
+		return p.length, 0
+	}
+	total := seconds(p.length) * 60 * (planned + t.flowTime) / planned
+	return minute(total / 60), total % 60
+}
+
+// Total length of the work phases of a session
+func (t task) sessionWorkLength() (length minute) {
+	for _, p := range t.phases {
+		if p.kind == phaseWork {
+			length += p.length
+		}
+	}
+	return
+}
+
 func (t task) ToString() string {
 	return "task"
 }
 
 func (t task) progress() (prog float64) {
-	sProg := float64(t.timer.sec) / 60
-	prog = (float64(t.timer.min) + sProg)
-	switch {
-	case t.isWorkInProgress():
-		prog -= float64(t.sessionLength)
-		prog = math.Abs(prog) / float64(t.sessionLength)
-	case t.isRestInProgress():
-		prog -= float64(t.restLength)
-		prog = math.Abs(prog) / float64(t.restLength)
+	if t.kind == taskKindStopwatch {
+		if t.target == 0 {
+			return 0
+		}
+		return math.Min(float64(t.workedTime)/float64(seconds(t.target)*60), 1)
 	}
-	return
+	if t.overtime {
+		return 1
+	}
+	if t.phaseTotal == 0 {
+		return 0
+	}
+	remaining := float64(t.timer.min)*60 + float64(t.timer.sec)
+	prog = 1 - remaining/float64(t.phaseTotal)
+	return math.Max(0, math.Min(prog, 1))
 }
 
 func (t task) isInProgress() bool {
@@ -187,58 +381,74 @@ func (t *task) changeState(new taskState) {
 	t.state = new
 }
 
-func (t *task) getWorkTime() string {
-	switch t.state {
-	case taskStateWork:
-		numberToString(int(t.timer.min), t.workText[:])
-		numberToString(int(t.timer.sec), t.workText[3:])
-	case taskStatePaused:
-		if t.previousState == taskStateWork {
-			numberToString(int(t.timer.min), t.workText[:])
-			numberToString(int(t.timer.sec), t.workText[3:])
-		} else {
-			numberToString(int(t.sessionLength), t.workText[:])
-			numberToString(0, t.workText[3:])
+// Index of the current or upcoming phase of the given kind,
+// wrapping around to the start of the program
+func (t *task) phaseOfKind(kind phaseKind) int {
+	for i := 0; i < len(t.phases); i += 1 {
+		index := (t.phaseIndex + i) % len(t.phases)
+		if t.phases[index].kind == kind {
+			return index
 		}
+	}
+	return -1
+}
+
+func (t *task) formatPhaseTime(kind phaseKind, buf []rune) string {
+	index := t.phaseOfKind(kind)
+	switch {
+	case index == t.phaseIndex && t.isInProgress():
+		numberToString(int(t.timer.min), buf)
+		numberToString(int(t.timer.sec), buf[3:])
+	case index >= 0:
+		numberToString(int(t.phases[index].length), buf)
+		numberToString(0, buf[3:])
 	default:
-		numberToString(int(t.sessionLength), t.workText[:])
-		numberToString(0, t.workText[3:])
+		numberToString(0, buf)
+		numberToString(0, buf[3:])
 	}
-	t.workText[2] = ':'
-	return string(t.workText[:])
+	buf[2] = ':'
+	return string(buf)
 }
 
-func (t *task) getRestTime() string {
-	switch t.state {
-	case taskStateRest:
-		numberToString(int(t.timer.min), t.restText[:])
-		numberToString(int(t.timer.sec), t.restText[3:])
-	case taskStatePaused:
-		if t.previousState == taskStateRest {
-			numberToString(int(t.timer.min), t.restText[:])
-			numberToString(int(t.timer.sec), t.restText[3:])
-		} else {
-			numberToString(int(t.restLength), t.restText[:])
-			numberToString(0, t.restText[3:])
+func (t *task) getWorkTime() string {
+	if t.kind == taskKindStopwatch {
+		return formatClock(t.workedTime)
+	}
+	if t.overtime {
+		// The display is capped, no need for a longer buffer
+		min := int(t.timer.min)
+		if min > 99 {
+			min = 99
 		}
-	default:
-		numberToString(int(t.restLength), t.restText[:])
-		numberToString(0, t.restText[3:])
+		numberToString(min, t.workText[:])
+		numberToString(int(t.timer.sec), t.workText[3:])
+		t.workText[2] = ':'
+		return "+" + string(t.workText[:])
+	}
+	return t.formatPhaseTime(phaseWork, t.workText[:])
+}
+
+// Time of the phase being run, the work one when idle
+func (t *task) getCurrentTime() string {
+	if t.isRestInProgress() {
+		return t.getRestTime()
 	}
-	t.restText[2] = ':'
-	return string(t.restText[:])
+	return t.getWorkTime()
+}
+
+func (t *task) getRestTime() string {
+	return t.formatPhaseTime(phaseRest, t.restText[:])
 }
 
 func newTaskBuffer() taskBuffer {
 	return taskBuffer{
 		items: make([]task, initialTaskCap),
-		cap:   initialTaskCap,
 	}
 }
 
 func (t *taskBuffer) addTask(newTask task) {
-	if t.count > len(t.items) {
-		newSlice := make([]task, t.cap*2)
+	if t.count >= len(t.items) {
+		newSlice := make([]task, len(t.items)*2)
 		copy(newSlice[:], t.items[:])
 		t.items = newSlice
 	}
@@ -258,6 +468,44 @@ func (t *taskBuffer) removeTask(id int) (at int) {
 	return -1
 }
 
+// At the index, or at the end when past it
+func (t *taskBuffer) insertTask(at int, newTask task) {
+	t.addTask(newTask)
+	if at < 0 || at >= t.count-1 {
+		return
+	}
+	copy(t.items[at+1:t.count], t.items[at:t.count-1])
+	t.items[at] = newTask
+}
+
+// The tasks in between shift by one toward the old place
+func (t *taskBuffer) moveTask(from, to int) {
+	moved := t.items[from]
+	if from < to {
+		copy(t.items[from:to], t.items[from+1:to+1])
+	} else {
+		copy(t.items[to+1:from+1], t.items[to:from])
+	}
+	t.items[to] = moved
+}
+
+// Nil when it isn't there anymore
+func (t *taskBuffer) find(id int) *task {
+	if at := t.indexOf(id); at > -1 {
+		return &t.items[at]
+	}
+	return nil
+}
+
+func (t *taskBuffer) indexOf(id int) int {
+	for i := 0; i < t.count; i += 1 {
+		if t.items[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
 func (t *taskBuffer) getTask(at int) *task {
 	return &t.items[at]
 }
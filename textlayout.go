@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"todo/shaping"
+)
+
+const ellipsis = "…"
+
+func (f *Font) fits(t string, size, width float64) bool {
+	return f.Advance(t, size) <= width
+}
+
+// Cuts the end of the text so it fits the width with an
+// ellipsis, returns true when it had to be cut
+func (f *Font) truncate(t string, size, width float64) (string, bool) {
+	if f.fits(t, size, width) {
+		return t, false
+	}
+	runes := []rune(t)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		cut := strings.TrimRight(string(runes), " ") + ellipsis
+		if f.fits(cut, size, width) {
+			return cut, true
+		}
+	}
+	return ellipsis, true
+}
+
+// Breaks the text between the words, the last line being
+// truncated when it doesn't fit in the maximum count
+func (f *Font) wrap(t string, size, width float64, maxLines int) ([]string, bool) {
+	words := strings.Fields(t)
+	lines := make([]string, 0, maxLines)
+	line := ""
+	for i, word := range words {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if line == "" || f.fits(next, size, width) {
+			line = next
+			continue
+		}
+		if len(lines) == maxLines-1 {
+			last, _ := f.truncate(strings.Join(append([]string{line}, words[i:]...), " "), size, width)
+			return append(lines, last), true
+		}
+		lines = append(lines, line)
+		line = word
+	}
+
+	// A single word can still be too long
+	last, cut := f.truncate(line, size, width)
+	for i := range lines {
+		var lineCut bool
+		lines[i], lineCut = f.truncate(lines[i], size, width)
+		cut = cut || lineCut
+	}
+	return append(lines, last), cut
+}
+
+// The first of the sizes, from the biggest, the text fits in.
+// The last one is returned when none does
+func (f *Font) shrinkToFit(t string, width float64, sizes ...float64) (float64, bool) {
+	for _, size := range sizes {
+		if f.fits(t, size, width) {
+			return size, true
+		}
+	}
+	return sizes[len(sizes)-1], false
+}
+
+// Where a caret placed before the logical rune index is drawn,
+// following the text once laid out from left to right
+func (f *Font) caretX(t string, caret int, size float64) float64 {
+	layout := shaping.Shape(t)
+	visual := string(layout.Visual[:layout.Caret(caret)])
+	x := fixed.Int26_6(0)
+	for _, run := range f.faceRuns(visual, size) {
+		x = run.x + font.MeasureString(run.face, run.text)
+	}
+	return f.unscale(x)
+}
@@ -1,30 +1,267 @@
 package main
 
 import (
-	_ "image/png"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
+const (
+	themesDirName   = "themes"
+	assetsDirName   = "assets"
+	defaultFontName = "FiraSans-Regular.ttf"
+)
+
+// Every colour of the UI by what it is used for, the
+// drawing code never using a colour value directly
+type Theme struct {
+	Name string
+
+	// From the windows to the modal backgrounds
+	Background1 Color
+	Background2 Color
+	Background3 Color
+	Separator   Color
+	Text        Color
+	MutedText   Color
+	Highlight   Color
+	Progress    Color
+
+	// The phases and timer states
+	Work    Color
+	Rest    Color
+	WarmUp  Color
+	Review  Color
+	Flow    Color
+	Warning Color
+}
+
+type themeColor struct {
+	key string
+	clr *Color
+}
+
 var (
-	darkBackground1 = Color{32, 32, 32, 255}
-	darkBackground2 = Color{25, 25, 25, 255}
-	darkBackground3 = Color{12, 12, 12, 255}
-	darkSeparator   = Color{100, 92, 87, 255}
+	darkTheme = Theme{
+		Name:        "Dark",
+		Background1: Color{32, 32, 32, 255},
+		Background2: Color{25, 25, 25, 255},
+		Background3: Color{12, 12, 12, 255},
+		Separator:   Color{100, 92, 87, 255},
+		Text:        Color{255, 255, 255, 255},
+		MutedText:   Color{255, 255, 255, 125},
+		Highlight:   Color{255, 255, 255, 125},
+		Progress:    Color{255, 255, 255, 125},
+		Work:        Color{235, 235, 235, 255},
+		Rest:        Color{120, 190, 230, 255},
+		WarmUp:      Color{240, 200, 110, 255},
+		Review:      Color{160, 220, 150, 255},
+		Flow:        Color{255, 170, 60, 255},
+		Warning:     Color{255, 120, 80, 255},
+	}
+
+	lightTheme = Theme{
+		Name:        "Light",
+		Background1: Color{245, 244, 241, 255},
+		Background2: Color{232, 230, 226, 255},
+		Background3: Color{205, 202, 197, 255},
+		Separator:   Color{180, 172, 165, 255},
+		Text:        Color{30, 30, 30, 255},
+		MutedText:   Color{30, 30, 30, 140},
+		Highlight:   Color{0, 0, 0, 40},
+		Progress:    Color{0, 0, 0, 60},
+		Work:        Color{60, 60, 60, 255},
+		Rest:        Color{40, 120, 180, 255},
+		WarmUp:      Color{190, 130, 20, 255},
+		Review:      Color{60, 150, 70, 255},
+		Flow:        Color{220, 110, 0, 255},
+		Warning:     Color{210, 60, 30, 255},
+	}
+
+	highContrastTheme = Theme{
+		Name:        "High contrast",
+		Background1: Color{0, 0, 0, 255},
+		Background2: Color{0, 0, 0, 255},
+		Background3: Color{0, 0, 0, 255},
+		Separator:   Color{255, 255, 255, 255},
+		Text:        Color{255, 255, 255, 255},
+		MutedText:   Color{255, 230, 0, 255},
+		Highlight:   Color{255, 230, 0, 110},
+		Progress:    Color{255, 255, 255, 90},
+		Work:        Color{255, 255, 255, 255},
+		Rest:        Color{0, 200, 255, 255},
+		WarmUp:      Color{255, 220, 0, 255},
+		Review:      Color{0, 255, 120, 255},
+		Flow:        Color{255, 160, 0, 255},
+		Warning:     Color{255, 60, 60, 255},
+	}
+)
+
+var (
+	// The current one, copied over when switching so the
+	// pointers to its colours stay valid
+	theme = darkTheme
+
+	// The built-in themes first, then the user ones
+	themes      []Theme
+	themeNames  []string
+	themeChoice int
 )
 
 var (
-	rectOutline    *ebiten.Image
-	rectConstraint = constraint{2, 2, 2, 2}
-	defaultFont    Font
-	timerWorkIcon  *ebiten.Image
-	timerRestIcon  *ebiten.Image
+	rectOutline     *ebiten.Image
+	rectConstraint  = constraint{2, 2, 2, 2}
+	defaultFont     Font
+	timerWorkIcon   *ebiten.Image
+	timerRestIcon   *ebiten.Image
+	timerWarmUpIcon *ebiten.Image
+	timerReviewIcon *ebiten.Image
 )
 
-func loadTheme() {
-	rectOutline, _, _ = ebitenutil.NewImageFromFile("assets/uiRectOutline.png")
-	defaultFont = NewFont("assets/FiraSans-Regular.ttf", 72, []int{smallTextSize, textSize, largeTextSize})
-	timerWorkIcon, _, _ = ebitenutil.NewImageFromFile("assets/icon-work-timer.png")
-	timerRestIcon, _, _ = ebitenutil.NewImageFromFile("assets/icon-rest-timer.png")
+func loadTheme() []error {
+	rectOutline = assets.image("uiRectOutline.png")
+	defaultFont = NewFont(defaultFontName, 72*uiScale, []int{smallTextSize, textSize, largeTextSize, focusTextSize})
+	timerWorkIcon = assets.image("icon-work-timer.png")
+	timerRestIcon = assets.image("icon-rest-timer.png")
+	timerWarmUpIcon = assets.image("icon-warmup.png")
+	timerReviewIcon = assets.image("icon-review.png")
+
+	errs := loadThemes()
+	if !selectTheme(userSettings.Theme) {
+		errs = append(errs, fmt.Errorf("unknown theme %q", userSettings.Theme))
+		selectTheme(darkTheme.Name)
+	}
+	return errs
+}
+
+func (t *Theme) colors() []themeColor {
+	return []themeColor{
+		{"background1", &t.Background1},
+		{"background2", &t.Background2},
+		{"background3", &t.Background3},
+		{"separator", &t.Separator},
+		{"text", &t.Text},
+		{"mutedText", &t.MutedText},
+		{"highlight", &t.Highlight},
+		{"progress", &t.Progress},
+		{"work", &t.Work},
+		{"rest", &t.Rest},
+		{"warmUp", &t.WarmUp},
+		{"review", &t.Review},
+		{"flow", &t.Flow},
+		{"warning", &t.Warning},
+	}
+}
+
+// The user themes are all the json files in the themes directory,
+// the broken ones being left out
+func loadThemes() []error {
+	themes = []Theme{darkTheme, lightTheme, highContrastTheme}
+
+	var errs []error
+	dir, err := themesDir()
+	if err != nil {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	sort.Strings(paths)
+	for _, path := range paths {
+		t, err := loadThemeFile(path)
+		if err == nil && findTheme(t.Name) >= 0 {
+			err = fmt.Errorf("a theme named %q already exists", t.Name)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		themes = append(themes, t)
+	}
+
+	themeNames = make([]string, len(themes))
+	for i := range themes {
+		themeNames[i] = themes[i].Name
+	}
+	return errs
+}
+
+func themesDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, settingsDirName, themesDirName), nil
+}
+
+// Every colour has to be given, as "#rrggbb" or "#rrggbbaa"
+func loadThemeFile(path string) (Theme, error) {
+	t := Theme{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return t, err
+	}
+	fields := map[string]string{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return t, err
+	}
+
+	t.Name = strings.TrimSpace(fields["name"])
+	if t.Name == "" {
+		return t, fmt.Errorf("missing name")
+	}
+	delete(fields, "name")
+	for _, c := range t.colors() {
+		value, exist := fields[c.key]
+		if !exist {
+			return t, fmt.Errorf("missing colour %q", c.key)
+		}
+		if *c.clr, err = parseHexColor(value); err != nil {
+			return t, fmt.Errorf("colour %q: %w", c.key, err)
+		}
+		delete(fields, c.key)
+	}
+	for key := range fields {
+		return t, fmt.Errorf("unknown colour %q", key)
+	}
+	return t, nil
+}
+
+func parseHexColor(s string) (Color, error) {
+	clr := Color{0, 0, 0, 255}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 && len(hex) != 8 || len(hex) == len(s) {
+		return clr, fmt.Errorf("%q is not a #rrggbb or #rrggbbaa colour", s)
+	}
+	for i := 0; i < len(hex)/2; i += 1 {
+		v, err := strconv.ParseUint(hex[i*2:i*2+2], 16, 8)
+		if err != nil {
+			return clr, fmt.Errorf("%q is not a #rrggbb or #rrggbbaa colour", s)
+		}
+		clr[i] = uint8(v)
+	}
+	return clr, nil
+}
+
+func findTheme(name string) int {
+	for i := range themes {
+		if strings.EqualFold(themes[i].Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func selectTheme(name string) bool {
+	i := findTheme(name)
+	if i < 0 {
+		return false
+	}
+	themeChoice = i
+	theme = themes[i]
+	return true
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	maxToasts     = 3
+	toastWidth    = 400
+	toastHeight   = 36
+	toastSpacing  = 6
+	toastDuration = 4 * 60
+	toastFadeTime = 30
+	// Longer when there is something to click
+	toastActionDuration = 8 * 60
+	toastActionWidth    = 90
+)
+
+type (
+	// Short lived banners drawn over the main window,
+	// the oldest one being dropped when full
+	toastQueue struct {
+		items [maxToasts]toast
+		count int
+		rect  rectangle
+		// The toast whose action is under the mouse, -1 if none
+		hovered int
+	}
+
+	// With an optional action, like undoing what it reports
+	toast struct {
+		text     string
+		ticks    int
+		action   string
+		onAction func()
+	}
+)
+
+// Centered at the top of the given area
+func (q *toastQueue) layout(bounds rectangle) {
+	q.rect = rectangle{
+		x:      bounds.x + (bounds.width-toastWidth)/2,
+		y:      bounds.y + 50,
+		width:  toastWidth,
+		height: toastHeight,
+	}
+}
+
+func (q *toastQueue) push(text string) {
+	q.add(toast{text: text, ticks: toastDuration})
+}
+
+func (q *toastQueue) pushAction(text, action string, onAction func()) {
+	q.add(toast{text: text, ticks: toastActionDuration, action: action, onAction: onAction})
+}
+
+func (q *toastQueue) add(t toast) {
+	if q.count == maxToasts {
+		copy(q.items[:], q.items[1:])
+		q.count -= 1
+		q.hovered = -1
+	}
+	q.items[q.count] = t
+	q.count += 1
+}
+
+func (q *toastQueue) itemRect(i int) rectangle {
+	rect := q.rect
+	rect.y += float64(i) * (toastHeight + toastSpacing)
+	return rect
+}
+
+func (q *toastQueue) actionRect(i int) rectangle {
+	const actionPadding = 4
+
+	rect := q.itemRect(i)
+	return rectangle{
+		rect.x + rect.width - toastActionWidth - actionPadding, rect.y + actionPadding,
+		toastActionWidth, rect.height - actionPadding*2,
+	}
+}
+
+// Runs the action clicked, the toast going away. Returns
+// true when the mouse is over one of the actions
+func (q *toastQueue) click(mPos point, mLeft bool) bool {
+	q.hovered = -1
+	for i := 0; i < q.count; i += 1 {
+		if q.items[i].action != "" && q.actionRect(i).boundCheck(mPos) {
+			q.hovered = i
+		}
+	}
+	if q.hovered == -1 {
+		return false
+	}
+	if mLeft {
+		onAction := q.items[q.hovered].onAction
+		copy(q.items[q.hovered:], q.items[q.hovered+1:q.count])
+		q.count -= 1
+		q.hovered = -1
+		onAction()
+	}
+	return true
+}
+
+func (q *toastQueue) update() {
+	kept := 0
+	for i := 0; i < q.count; i += 1 {
+		q.items[i].ticks -= 1
+		// Kept while about to be clicked
+		if i == q.hovered && q.items[i].ticks < toastFadeTime {
+			q.items[i].ticks = toastFadeTime
+		}
+		if q.items[i].ticks > 0 {
+			q.items[kept] = q.items[i]
+			kept += 1
+		}
+	}
+	q.count = kept
+}
+
+func (q *toastQueue) draw(dst *ebiten.Image) {
+	for i := 0; i < q.count; i += 1 {
+		t := &q.items[i]
+		alpha := 1.0
+		if t.ticks < toastFadeTime {
+			alpha = float64(t.ticks) / toastFadeTime
+		}
+		rect := q.itemRect(i)
+
+		bg := theme.Background3
+		bg[3] = uint8(230 * alpha)
+		clr := theme.Text
+		clr[3] = uint8(255 * alpha)
+		drawRect(dst, rect, bg)
+		drawImageSlice(dst, rect, rectOutline, rectConstraint, clr)
+		if t.action != "" {
+			action := q.actionRect(i)
+			if i == q.hovered {
+				drawRect(dst, action, theme.Highlight)
+			}
+			drawColoredTextBtn(dst, action, t.action, smallTextSize, clr)
+			rect.width -= toastActionWidth
+		}
+		drawTextCenter(dst, textOptions{
+			font: &defaultFont, text: t.text, bounds: rect,
+			size: smallTextSize, clr: clr,
+		})
+	}
+}
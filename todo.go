@@ -4,10 +4,11 @@ import (
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
+const windowTitle = "Get work done"
+
 const (
 	windowWidth    = 800
 	windowHeight   = 600
@@ -19,6 +20,14 @@ const (
 	btnHeight      = textSize + (10 * 2)
 )
 
+// The main window content needs some room, the list can be narrower
+const (
+	minWindowWidth  = 640
+	minWindowHeight = 540
+	minListWidth    = 150
+	minMainWidth    = 480
+)
+
 const (
 	todoAddBtnPressed SignalKind = iota
 	todoAddWindowClosed
@@ -29,15 +38,53 @@ const (
 	todoTaskRemoveAnimationDone
 	todoTaskStarted
 	todoTaskStopped
+	todoStatsBtnPressed
+	todoStatsWindowClosed
+	todoSettingsBtnPressed
+	todoSettingsWindowClosed
+	todoSettingsChanged
+	todoSettingsFailed
+	todoTaskBreakTaken
+	todoDialogOpened
+	todoDialogClosed
+	todoIdleResolved
+	todoTaskWorkEnded
+	todoTaskRestEnded
+	todoTaskCompleted
+	todoTaskWarning
+	todoFocusBtnPressed
+	todoMiniBtnPressed
+	todoTaskRestStarted
+	todoBreakScreenOpened
+	todoBreakScreenClosed
+	todoTaskEdited
+	todoTaskDeleted
+	todoTaskRestored
+	todoSessionsChanged
 )
 
 var todo *Todo
 
+// Current size of the app, changes when the window is resized
+var screenBounds = rectangle{0, 0, windowWidth, windowHeight}
+
+// Pixels per layout unit, the display scale times the user one.
+// Everything is laid out unscaled, only the drawing helpers use it
+var uiScale = 1.0
+
+func userScale() float64 {
+	return float64(userSettings.UIScale) / 100
+}
+
 type (
 	Todo struct {
 		tasks   taskBuffer
 		archive taskBuffer
 		taskID  int
+		// Undo and redo of the changes to the tasks
+		history history
+		// The task going away with the animation is deleted, not archived
+		deleting bool
 
 		selected   *task
 		windowOpen bool
@@ -58,6 +105,37 @@ type (
 		// Archive window
 		archiveWindow archiveWindow
 
+		// Estimation statistics window
+		statsWindow statsWindow
+
+		settingsWindow settingsWindow
+
+		// Distraction free view of the selected task
+		focus focusView
+
+		// Floating strip replacing the whole window
+		mini miniWindow
+
+		// Dragging the line between the list and the main window
+		separatorDrag bool
+
+		// Theme files and assets, loaded again when edited
+		themeWatch themeWatcher
+
+		breakScreen breakScreen
+
+		// Modal dialog and its users
+		dialog dialogWindow
+		idle   idleDetector
+
+		audio   audioSystem
+		ambient ambientNoise
+
+		// Notifications and the in-app toasts
+		notifier notifier
+		toasts   toastQueue
+		tooltip  tooltipView
+
 		signals signalDispatcher
 	}
 )
@@ -66,7 +144,12 @@ func a(i int) {}
 
 func (t *Todo) Init() {
 	todo = t
-	loadTheme()
+	settingsErr := loadSettings()
+	uiScale = ebiten.DeviceScaleFactor() * userScale()
+	themeErrs := loadTheme()
+	localeErrs := loadLocales()
+	programErrs := loadPrograms()
+	selectLanguage(userSettings.Language)
 
 	// Caching all the rects possible
 	// and init the subsytems
@@ -81,39 +164,242 @@ func (t *Todo) Init() {
 	t.signals.addListener(todoAddWindowClosed, t)
 	t.signals.addListener(todoArchiveBtnPressed, t)
 	t.signals.addListener(todoArchiveWindowClosed, t)
+	t.signals.addListener(todoStatsBtnPressed, t)
+	t.signals.addListener(todoStatsWindowClosed, t)
+	t.signals.addListener(todoSettingsBtnPressed, t)
+	t.signals.addListener(todoSettingsWindowClosed, t)
+	t.signals.addListener(todoTaskBreakTaken, t)
+	t.signals.addListener(todoDialogOpened, t)
+	t.signals.addListener(todoDialogClosed, t)
+	t.signals.addListener(todoIdleResolved, t)
 	t.signals.addListener(todoTaskAdded, t)
 	t.signals.addListener(todoTaskStarted, t)
 	t.signals.addListener(todoTaskStopped, t)
+	t.signals.addListener(todoTaskRemoved, t)
+	t.signals.addListener(todoTaskDeleted, t)
 	t.signals.addListener(todoTaskRemoveAnimationDone, t)
+	t.signals.addListener(todoTaskRestored, t)
+	t.signals.addListener(todoSessionsChanged, t)
+	t.signals.addListener(todoFocusBtnPressed, t)
+	t.signals.addListener(todoMiniBtnPressed, t)
+	t.signals.addListener(todoBreakScreenOpened, t)
+	t.signals.addListener(todoBreakScreenClosed, t)
+	t.signals.addListener(todoSettingsChanged, t)
+	t.signals.addListener(todoTaskEdited, t)
 
 	// Resources
-	t.font = NewFont("assets/FiraSans-Regular.ttf", 72, []int{smallTextSize, textSize, largeTextSize})
-	t.rectOutline, _, _ = ebitenutil.NewImageFromFile("assets/uiRectOutline.png")
+	t.font = NewFont(defaultFontName, 72*uiScale, []int{smallTextSize, textSize, largeTextSize})
+	t.rectOutline = rectOutline
 
 	// List window init
 	t.list.init(&t.font, t.rectOutline)
 
 	// Main window init
 	t.mainWindow.init(&t.font, t.rectOutline)
+	t.focus.init()
+	t.mini.init(&t.font)
+	t.breakScreen.init(&t.font)
 
 	// Add window
 	t.addWindow.init(&t.font, t.rectOutline)
 
 	// Add archive window
 	t.archiveWindow.init(&t.font, t.rectOutline)
+
+	// Statistics window
+	t.statsWindow.init(&t.font, t.rectOutline)
+
+	// Settings window
+	t.settingsWindow.init(&t.font, t.rectOutline)
+
+	// Dialog
+	t.dialog.init(&t.font, t.rectOutline)
+
+	// Sound cues
+	t.audio.init()
+	t.ambient.init()
+
+	// Notifications
+	t.notifier.init(&t.toasts)
+	if settingsErr != nil {
+		t.notifier.failSettings(settingsErr.Error())
+	}
+	for _, err := range themeErrs {
+		t.toasts.push(tr("Theme {error}", "{error}", err.Error()))
+	}
+	for _, err := range programErrs {
+		t.toasts.push(tr("Program {error}", "{error}", err.Error()))
+	}
+	for _, err := range localeErrs {
+		t.toasts.push(tr("Language {error}", "{error}", err.Error()))
+	}
+	for _, err := range assets.takeErrors() {
+		t.toasts.push(tr("Asset {error}", "{error}", err.Error()))
+	}
+
+	t.themeWatch.init()
+	t.relayout()
+}
+
+// Builds all the layouts again for the current size,
+// the windows keeping their state
+func (t *Todo) relayout() {
+	listWidth := t.listWidth()
+	mainRect := rectangle{listWidth, 0, screenBounds.width - listWidth, screenBounds.height}
+
+	t.list.layout(rectangle{0, 0, listWidth, screenBounds.height})
+	t.mainWindow.layout(mainRect)
+	t.addWindow.layout()
+	t.archiveWindow.layout()
+	t.statsWindow.layout()
+	t.settingsWindow.layout()
+	t.dialog.layout()
+	t.focus.layout(mainRect)
+	t.breakScreen.layout()
+	t.toasts.layout(mainRect)
+	t.updateWindowRect()
+}
+
+// The input is kept from what is under the top most window,
+// the modal ones covering everything
+func (t *Todo) updateWindowRect() {
+	t.windowOpen = true
+	switch {
+	case t.dialog.active, t.breakScreen.active:
+		t.windowRect = screenBounds
+	case t.addWindow.active:
+		t.windowRect = t.addWindow.rect.full.addPoint(t.addWindow.position)
+	case t.archiveWindow.active:
+		t.windowRect = t.archiveWindow.rect.full.addPoint(t.archiveWindow.position)
+	case t.statsWindow.active:
+		t.windowRect = t.statsWindow.rect.full.addPoint(t.statsWindow.position)
+	case t.settingsWindow.active:
+		t.windowRect = t.settingsWindow.rect.full.addPoint(t.settingsWindow.position)
+	default:
+		t.windowOpen = false
+		t.windowRect = rectangle{}
+	}
+}
+
+// Switches to the theme picked in the settings, the
+// cached drawings being redone with the new colours
+func (t *Todo) applyTheme() {
+	if themes[themeChoice].Name == userSettings.Theme {
+		return
+	}
+	selectTheme(themeNames[themeChoice])
+	userSettings.Theme = theme.Name
+	storeSettings()
+	t.relayout()
+}
+
+// Switches to the language picked in the settings, the
+// buttons being sized again for the new text
+func (t *Todo) applyLanguage() {
+	if chosenLanguage() == userSettings.Language {
+		return
+	}
+	userSettings.Language = chosenLanguage()
+	selectLanguage(userSettings.Language)
+	storeSettings()
+	ebiten.SetWindowTitle(tr(windowTitle))
+	t.relayout()
+}
+
+// The saved width, kept so the main window always fits
+func (t *Todo) listWidth() float64 {
+	width := float64(userSettings.ListWidth)
+	if max := screenBounds.width - minMainWidth; width > max {
+		width = max
+	}
+	if width < minListWidth {
+		width = minListWidth
+	}
+	return width
+}
+
+// Returns true while the separator is being dragged,
+// nothing else getting the input then
+func (t *Todo) updateSeparator(mPos point) bool {
+	const grabDistance = 3
+
+	if t.windowOpen || t.focus.active {
+		return false
+	}
+	listWidth := t.listWidth()
+	near := mPos[0] >= listWidth-grabDistance && mPos[0] <= listWidth+grabDistance
+	if near && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		t.separatorDrag = true
+	}
+	if t.separatorDrag {
+		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+			if int(mPos[0]) != int(listWidth) {
+				userSettings.ListWidth = int(mPos[0])
+				t.relayout()
+			}
+		} else {
+			t.separatorDrag = false
+			userSettings.ListWidth = int(t.listWidth())
+			storeSettings()
+		}
+	}
+
+	if near || t.separatorDrag {
+		ebiten.SetCursorShape(ebiten.CursorShapeEWResize)
+	} else {
+		ebiten.SetCursorShape(ebiten.CursorShapeDefault)
+	}
+	return t.separatorDrag
 }
 
 func (t *Todo) Update() error {
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+	// Escape only leaves the focus mode when in it
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) && !t.focus.active {
 		return exitStatus{kind: exitNoError}
 	}
 
 	mx, my := ebiten.CursorPosition()
-	mPos := point{float64(mx), float64(my)}
+	mPos := point{float64(mx) / uiScale, float64(my) / uiScale}
 	mLeft := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
 
+	t.updateIdle(mPos)
+	if t.themeWatch.update() {
+		t.reloadTheme()
+	}
+	if t.mini.active {
+		t.mini.update(mPos)
+		t.updateTasks()
+		return nil
+	}
+	if t.dialog.active {
+		// Modal, nothing else gets the input
+		t.dialog.update(mPos, mLeft)
+		mLeft = false
+	}
+	if t.breakScreen.active {
+		t.breakScreen.update(mPos, &t.tasks)
+		mLeft = false
+	}
+	if t.updateSeparator(mPos) {
+		mLeft = false
+	}
+
+	t.updateHistory()
+	t.focus.update(&t.tasks)
+	if t.focus.active {
+		// Only the timers keep running underneath
+		mLeft = false
+		mPos = point{-1, -1}
+	}
+	// After the focus mode, an undo would change the tasks under it
+	if t.toasts.click(mPos, mLeft) {
+		mLeft = false
+	}
+
 	t.addWindow.update(mPos, mLeft)
 	t.archiveWindow.update(mPos, mLeft)
+	t.statsWindow.update(mPos, mLeft)
+	t.settingsWindow.update(mPos, mLeft)
 
 	selected := t.list.update(mPos, mLeft)
 	if selected >= 0 {
@@ -121,36 +407,101 @@ func (t *Todo) Update() error {
 	}
 
 	t.mainWindow.update(mPos, mLeft, t.selected)
+	t.tooltip.update()
+
+	t.updateTasks()
+	return nil
+}
 
-	// Advance all the timer and check for completed sessions
+// Advance all the timer and check for completed sessions
+func (t *Todo) updateTasks() {
+	working := false
 	for i := 0; i < t.tasks.count; i += 1 {
 		task := t.tasks.getTask(i)
 		task.update()
+		if task.state == taskStateWork {
+			working = true
+		}
 	}
-
-	return nil
+	t.ambient.update(working)
+	t.toasts.update()
 }
 
 func (t *Todo) Draw(screen *ebiten.Image) {
-	screen.Fill(darkBackground1)
+	screen.Fill(theme.Background1)
+	if t.mini.active {
+		t.mini.draw(screen, t.miniTask())
+		return
+	}
 	t.list.draw(screen, t.tasks.items[:t.tasks.count])
 
 	t.mainWindow.draw(screen, t.selected)
+	t.focus.draw(screen, &t.tasks)
 
 	t.addWindow.draw(screen)
 	t.archiveWindow.draw(screen, t.archive.items[:t.archive.count])
+	t.statsWindow.draw(screen, t.archive.items[:t.archive.count])
+	t.settingsWindow.draw(screen)
+	t.breakScreen.draw(screen, &t.tasks)
+	t.toasts.draw(screen)
+	t.dialog.draw(screen)
+	t.tooltip.draw(screen)
 }
 
 func (t *Todo) Layout(outW, outH int) (int, int) {
-	return windowWidth, windowHeight
+	scaleChanged := t.updateScale()
+	if t.mini.active {
+		return int(miniWindowWidth * uiScale), int(miniWindowHeight * uiScale)
+	}
+	// The window size is in device independent pixels,
+	// only the user scale changes the layout size
+	width := float64(outW) / userScale()
+	height := float64(outH) / userScale()
+	// The window size limits should already take care of it
+	if width < minWindowWidth {
+		width = minWindowWidth
+	}
+	if height < minWindowHeight {
+		height = minWindowHeight
+	}
+	if scaleChanged || width != screenBounds.width || height != screenBounds.height {
+		screenBounds = rectangle{0, 0, width, height}
+		t.relayout()
+	}
+	return int(width * uiScale), int(height * uiScale)
+}
+
+// The fonts are rasterized again when the user scale is changed
+// or the window moves to a screen with another scale factor
+func (t *Todo) updateScale() bool {
+	scale := ebiten.DeviceScaleFactor() * userScale()
+	if scale == uiScale {
+		return false
+	}
+	uiScale = scale
+	t.font.setDPI(72 * uiScale)
+	defaultFont.setDPI(72 * uiScale)
+	if t.mini.active {
+		ebiten.SetWindowSize(t.mini.windowSize())
+	} else {
+		setWindowSizeLimits()
+	}
+	return true
+}
+
+// The minimum size grows with the user scale
+func setWindowSizeLimits() {
+	ebiten.SetWindowSizeLimits(
+		int(minWindowWidth*userScale()), int(minWindowHeight*userScale()), -1, -1,
+	)
 }
 
 func (t *Todo) addTask(_t task) {
 	newTask := _t
 	newTask.id = t.genID()
+	newTask.createdAt = time.Now()
 	newTask.init()
-	t.tasks.addTask(newTask)
-	t.list.addItem()
+	t.changeTasks(func() { t.history.run(t, &addTaskCommand{task: newTask}) })
 }
 
 func (t *Todo) OnSignal(s Signal) {
@@ -167,22 +518,66 @@ func (t *Todo) OnSignal(s Signal) {
 	case todoArchiveWindowClosed:
 		t.windowOpen = false
 		t.windowRect = rectangle{}
+	case todoStatsBtnPressed:
+		t.windowOpen = true
+		t.windowRect = t.statsWindow.rect.full.addPoint(t.statsWindow.position)
+	case todoStatsWindowClosed:
+		t.windowOpen = false
+		t.windowRect = rectangle{}
+	case todoSettingsBtnPressed:
+		t.windowOpen = true
+		t.windowRect = t.settingsWindow.rect.full.addPoint(t.settingsWindow.position)
+	case todoSettingsWindowClosed:
+		t.windowOpen = false
+		t.windowRect = rectangle{}
 	case todoTaskAdded:
 		t.addTask(s.Value.(task))
 	case todoTaskStarted:
 		t.selected.startWork()
 	case todoTaskStopped:
 		t.selected.stopWork()
+	case todoTaskBreakTaken:
+		t.selected.takeBreak()
+	case todoDialogOpened:
+		t.windowOpen = true
+		t.windowRect = screenBounds
+	case todoDialogClosed:
+		// Back to the window it was opened over, if any
+		t.updateWindowRect()
+	case todoFocusBtnPressed:
+		if t.selected.state == taskStateIdle || t.selected.state == taskStatePaused {
+			t.selected.startWork()
+		}
+		t.focus.enter(t.selected)
+	case todoMiniBtnPressed:
+		t.mini.enter()
+	case todoBreakScreenOpened:
+		t.windowOpen = true
+		t.windowRect = screenBounds
+	case todoBreakScreenClosed:
+		t.updateWindowRect()
+	case todoSettingsChanged:
+		t.applyTheme()
+		t.applyLanguage()
+	case todoIdleResolved:
+		t.resolveIdle(idleChoice(s.Value.(SignalInt)))
+	case todoTaskRemoved:
+		t.deleting = false
+	case todoTaskDeleted:
+		t.deleting = true
 	case todoTaskRemoveAnimationDone:
 		// This is always the currently selected one
-		copied := t.tasks.copyTask(t.selected.id)
-		at := t.tasks.removeTask(t.selected.id)
-		if at > -1 {
-			t.list.removeItem(at)
-			t.archiveWindow.addItem()
-			t.selected = nil
-			t.archive.addTask(copied)
+		if t.deleting {
+			t.deleteSelected()
+		} else {
+			t.archiveSelected()
 		}
+	case todoTaskEdited:
+		t.history.push(s.Value.(*editNotesCommand))
+	case todoTaskRestored:
+		t.restoreArchived(int(s.Value.(SignalInt)))
+	case todoSessionsChanged:
+		t.changeSessions(int(s.Value.(SignalInt)))
 	}
 }
 
@@ -0,0 +1,77 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+const (
+	tooltipDelay    = 30
+	tooltipMaxWidth = 300
+	tooltipMaxLines = 4
+	tooltipPadding  = 6
+)
+
+// Full text of what was cut, shown after hovering it for a moment.
+// The windows ask for it on every update while hovered
+type tooltipView struct {
+	text      string
+	anchor    rectangle
+	requested bool
+	ticks     int
+}
+
+// Static wrapper, like the signal ones
+func showTooltip(text string, anchor rectangle) {
+	todo.tooltip.show(text, anchor)
+}
+
+func (t *tooltipView) show(text string, anchor rectangle) {
+	if text != t.text {
+		t.ticks = 0
+	}
+	t.text = text
+	t.anchor = anchor
+	t.requested = true
+}
+
+// Called once all the windows are updated
+func (t *tooltipView) update() {
+	if !t.requested {
+		t.text = ""
+		t.ticks = 0
+	} else if t.ticks < tooltipDelay {
+		t.ticks += 1
+	}
+	t.requested = false
+}
+
+func (t *tooltipView) draw(dst *ebiten.Image) {
+	if t.text == "" || t.ticks < tooltipDelay {
+		return
+	}
+	lines, _ := defaultFont.wrap(t.text, smallTextSize, tooltipMaxWidth, tooltipMaxLines)
+	width := 0.0
+	for _, line := range lines {
+		width = maxFloat(width, defaultFont.Advance(line, smallTextSize))
+	}
+	lineHeight := float64(smallTextSize + 4)
+	rect := rectangle{
+		x:      t.anchor.x,
+		y:      t.anchor.y + t.anchor.height + 4,
+		width:  width + tooltipPadding*2,
+		height: lineHeight*float64(len(lines)) + tooltipPadding*2,
+	}
+	// Kept inside the app, above the anchor when there is no room below
+	rect.x = minFloat(rect.x, screenBounds.width-rect.width)
+	if rect.y+rect.height > screenBounds.height {
+		rect.y = t.anchor.y - rect.height - 4
+	}
+
+	drawRect(dst, rect, theme.Background3)
+	drawImageSlice(dst, rect, rectOutline, rectConstraint, theme.MutedText)
+	for i, line := range lines {
+		drawText(dst, textOptions{
+			font: &defaultFont, text: line,
+			pos:  point{rect.x + tooltipPadding, rect.y + tooltipPadding + float64(i)*lineHeight},
+			size: smallTextSize, clr: theme.Text,
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package ui
+
+// Widgets updated and drawn together. The children are placed by
+// the Layout function when the group is given its bounds, usually
+// by cutting them with a rect layout
+type Group struct {
+	Base
+	Children []Widget
+	Layout   func(r Rect)
+}
+
+func (g *Group) Add(w Widget) {
+	g.Children = append(g.Children, w)
+}
+
+func (g *Group) Clear() {
+	g.Children = g.Children[:0]
+}
+
+func (g *Group) SetBounds(r Rect) {
+	g.rect = r
+	if g.Layout != nil {
+		g.Layout(r)
+	}
+}
+
+func (g *Group) Update(in *Input) bool {
+	if g.state.Has(Hidden) {
+		return false
+	}
+	changed := false
+	for _, child := range g.Children {
+		if child.State().Has(Hidden) {
+			continue
+		}
+		before := child.State()
+		if child.Update(in) || child.State() != before {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (g *Group) Draw(p Painter) {
+	if g.state.Has(Hidden) {
+		return
+	}
+	for _, child := range g.Children {
+		if !child.State().Has(Hidden) {
+			child.Draw(p)
+		}
+	}
+}
+
+// Top of the widgets of a window, only one of them
+// having the keyboard at a time
+type Root struct {
+	Group
+	focused Focusable
+}
+
+func (r *Root) Update(in *Input) bool {
+	changed := r.Group.Update(in)
+
+	var focusables []Focusable
+	walk(r.Children, func(w Widget) {
+		if f, ok := w.(Focusable); ok && f.focusable() {
+			focusables = append(focusables, f)
+		}
+	})
+	current := -1
+	for i, f := range focusables {
+		// The one that just took the focus wins
+		if f.State().Has(Focused) && (current == -1 || Widget(f) != Widget(r.focused)) {
+			current = i
+		}
+	}
+	if in.KeyPressed(KeyTab) && len(focusables) > 0 {
+		current = (current + 1) % len(focusables)
+		changed = true
+	}
+
+	r.focused = nil
+	for i, f := range focusables {
+		if i == current {
+			r.focused = f
+		}
+		if f.State().Has(Focused) != (i == current) {
+			f.SetState(Focused, i == current)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// The widget with the keyboard, nil if none
+func (r *Root) Focused() Widget {
+	if r.focused == nil {
+		return nil
+	}
+	return r.focused
+}
+
+func (r *Root) Blur() {
+	if r.focused != nil {
+		r.focused.SetState(Focused, false)
+		r.focused = nil
+	}
+}
+
+type container interface {
+	children() []Widget
+}
+
+func (g *Group) children() []Widget {
+	return g.Children
+}
+
+// Every visible and enabled widget, in order
+func walk(widgets []Widget, f func(w Widget)) {
+	for _, w := range widgets {
+		if w.State().Has(Hidden) || w.State().Has(Disabled) {
+			continue
+		}
+		f(w)
+		if c, ok := w.(container); ok {
+			walk(c.children(), f)
+		}
+	}
+}
+
+// Rows of the same height scrolled with the wheel, only
+// the rows fully inside the bounds being drawn
+type List struct {
+	Base
+	RowHeight  float64
+	Separators bool
+	Scroll     float64
+	ScrollStep float64
+	rows       []Widget
+}
+
+const defaultScrollStep = 20
+
+func (l *List) Add(w Widget) {
+	l.rows = append(l.rows, w)
+	l.placeRow(len(l.rows) - 1)
+}
+
+func (l *List) Clear() {
+	l.rows = l.rows[:0]
+}
+
+func (l *List) Len() int {
+	return len(l.rows)
+}
+
+func (l *List) SetBounds(r Rect) {
+	l.rect = r
+	for i := range l.rows {
+		l.placeRow(i)
+	}
+	l.clampScroll()
+}
+
+// Without the scroll, which is applied to the input and the drawing
+func (l *List) placeRow(i int) {
+	l.rows[i].SetBounds(Rect{l.rect.X, l.rect.Y + float64(i)*l.RowHeight, l.rect.Width, l.RowHeight})
+}
+
+func (l *List) clampScroll() {
+	maxScroll := float64(len(l.rows))*l.RowHeight - l.rect.Height
+	if l.Scroll > maxScroll {
+		l.Scroll = maxScroll
+	}
+	if l.Scroll < 0 {
+		l.Scroll = 0
+	}
+}
+
+// The row under the position, -1 if none
+func (l *List) RowAt(x, y float64) int {
+	if !l.rect.Contains(x, y) {
+		return -1
+	}
+	i := int((y - l.rect.Y + l.Scroll) / l.RowHeight)
+	if i < 0 || i >= len(l.rows) {
+		return -1
+	}
+	return i
+}
+
+func (l *List) visible(i int) bool {
+	y := l.rect.Y + float64(i)*l.RowHeight - l.Scroll
+	return y >= l.rect.Y && y+l.RowHeight <= l.rect.Y+l.rect.Height
+}
+
+func (l *List) Update(in *Input) bool {
+	if l.state.Has(Hidden) {
+		return false
+	}
+	before := l.state
+	l.track(in, l.rect)
+	changed := false
+
+	if l.state.Has(Hovered) && in.Wheel != 0 {
+		step := l.ScrollStep
+		if step == 0 {
+			step = defaultScrollStep
+		}
+		scroll := l.Scroll
+		l.Scroll -= in.Wheel * step
+		l.clampScroll()
+		changed = l.Scroll != scroll
+	}
+
+	// Out of the bounds, nothing in the list can be under the mouse
+	rowInput := in.offset(0, l.Scroll)
+	if !l.rect.Contains(in.X, in.Y) {
+		rowInput.X, rowInput.Y = l.rect.X-1, l.rect.Y-1
+	}
+	for i, row := range l.rows {
+		if row.State().Has(Hidden) {
+			continue
+		}
+		rowState := row.State()
+		if !l.visible(i) {
+			// Still losing the focus on a press elsewhere
+			outside := *rowInput
+			outside.X, outside.Y = l.rect.X-1, l.rect.Y-1
+			row.Update(&outside)
+		} else if row.Update(rowInput) {
+			changed = true
+		}
+		if row.State() != rowState {
+			changed = true
+		}
+	}
+	return changed || l.state != before
+}
+
+func (l *List) Draw(p Painter) {
+	if l.state.Has(Hidden) {
+		return
+	}
+	moved := translated{p, 0, -l.Scroll}
+	for i, row := range l.rows {
+		if !l.visible(i) || row.State().Has(Hidden) {
+			continue
+		}
+		row.Draw(moved)
+		if l.Separators {
+			r := row.Bounds()
+			moved.Rect(Rect{r.X, r.Y + r.Height - 1, r.Width, 1}, ColorSeparator)
+		}
+	}
+}
+
+func (l *List) children() []Widget {
+	return l.rows
+}
+
+// Draws everything moved by the offset
+type translated struct {
+	Painter
+	dx, dy float64
+}
+
+func (t translated) Rect(r Rect, c Color) {
+	t.Painter.Rect(r.Offset(t.dx, t.dy), c)
+}
+
+func (t translated) Frame(r Rect, c Color) {
+	t.Painter.Frame(r.Offset(t.dx, t.dy), c)
+}
+
+func (t translated) Text(r Rect, text string, size Size, align Align, c Color) {
+	t.Painter.Text(r.Offset(t.dx, t.dy), text, size, align, c)
+}
+
+func (t translated) Icon(r Rect, icon Icon, c Color) {
+	t.Painter.Icon(r.Offset(t.dx, t.dy), icon, c)
+}
@@ -0,0 +1,225 @@
+// Package ui is a small retained mode toolkit: the widgets keep their
+// state between the frames, follow the input given on each update and
+// call back when they are used. The drawing goes through a Painter so
+// the package doesn't depend on the renderer or the theme
+package ui
+
+type Rect struct {
+	X, Y, Width, Height float64
+}
+
+func (r Rect) Contains(x, y float64) bool {
+	return x >= r.X && x <= r.X+r.Width && y >= r.Y && y <= r.Y+r.Height
+}
+
+func (r Rect) Offset(dx, dy float64) Rect {
+	return Rect{r.X + dx, r.Y + dy, r.Width, r.Height}
+}
+
+type State uint8
+
+const (
+	Hovered State = 1 << iota
+	Pressed
+	Disabled
+	Focused
+	Hidden
+)
+
+func (s State) Has(flag State) bool {
+	return s&flag != 0
+}
+
+type Key int
+
+const (
+	KeyBackspace Key = iota
+	KeyDelete
+	KeyLeft
+	KeyRight
+	KeyUp
+	KeyDown
+	KeyHome
+	KeyEnd
+	KeyEnter
+	KeyEscape
+	KeyTab
+)
+
+// Everything the widgets need to know about the input for one
+// update, the position being in the same space as their bounds
+type Input struct {
+	X, Y float64
+	// The left button was pressed during this update, and is held
+	Pressed bool
+	Down    bool
+	Wheel   float64
+	Shift   bool
+
+	Chars []rune
+	// Just pressed or repeated
+	Keys []Key
+
+	Metrics Metrics
+}
+
+func (in *Input) KeyPressed(k Key) bool {
+	for _, key := range in.Keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Moved by the offset, the keys staying the same
+func (in *Input) offset(dx, dy float64) *Input {
+	moved := *in
+	moved.X += dx
+	moved.Y += dy
+	return &moved
+}
+
+type Size int
+
+const (
+	SizeSmall Size = iota
+	SizeNormal
+	SizeLarge
+)
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+)
+
+// What the colours are used for, the painter picks them from its theme
+type Color int
+
+const (
+	ColorText Color = iota
+	ColorMuted
+	ColorHighlight
+	ColorSeparator
+	ColorBackground
+)
+
+// Opaque to the package, only given back to the painter
+type Icon interface{}
+
+type Metrics interface {
+	Advance(text string, size Size) float64
+	// Where the caret placed before the logical rune index is drawn
+	CaretX(text string, caret int, size Size) float64
+}
+
+type Painter interface {
+	Metrics
+	Rect(r Rect, c Color)
+	Frame(r Rect, c Color)
+	// Cut with an ellipsis when it doesn't fit
+	Text(r Rect, text string, size Size, align Align, c Color)
+	Icon(r Rect, icon Icon, c Color)
+}
+
+type Widget interface {
+	Bounds() Rect
+	SetBounds(r Rect)
+	State() State
+	SetState(flag State, on bool)
+	// Returns true when it has to be drawn again
+	Update(in *Input) bool
+	Draw(p Painter)
+}
+
+// The widgets that can be given the keyboard
+type Focusable interface {
+	Widget
+	focusable() bool
+}
+
+// Bounds and state shared by all the widgets, meant to be embedded
+type Base struct {
+	rect  Rect
+	state State
+}
+
+func (b *Base) Bounds() Rect {
+	return b.rect
+}
+
+func (b *Base) SetBounds(r Rect) {
+	b.rect = r
+}
+
+func (b *Base) State() State {
+	return b.state
+}
+
+func (b *Base) SetState(flag State, on bool) {
+	if on {
+		b.state |= flag
+	} else {
+		b.state &^= flag
+	}
+	if b.state.Has(Disabled | Hidden) {
+		b.state &^= Hovered | Pressed
+	}
+}
+
+func (b *Base) SetDisabled(disabled bool) {
+	b.SetState(Disabled, disabled)
+}
+
+func (b *Base) SetHidden(hidden bool) {
+	b.SetState(Hidden, hidden)
+}
+
+func (b *Base) active() bool {
+	return !b.state.Has(Disabled) && !b.state.Has(Hidden)
+}
+
+// Follows the mouse over the bounds, returning true on a click:
+// pressed over the widget and released still over it
+func (b *Base) track(in *Input, bounds Rect) (clicked bool) {
+	hovered := b.active() && bounds.Contains(in.X, in.Y)
+	b.SetState(Hovered, hovered)
+	if hovered && in.Pressed {
+		b.SetState(Pressed, true)
+	}
+	if b.state.Has(Pressed) && !in.Down {
+		b.SetState(Pressed, false)
+		clicked = hovered
+	}
+	return clicked
+}
+
+// Given the keyboard when pressed, losing it when
+// the press is somewhere else
+func (b *Base) trackFocus(in *Input) {
+	if in.Pressed {
+		b.SetState(Focused, b.active() && b.rect.Contains(in.X, in.Y))
+	}
+	if !b.active() {
+		b.SetState(Focused, false)
+	}
+}
+
+// The highlight of the hovered and focused widgets
+func (b *Base) drawHighlight(p Painter, r Rect) {
+	if b.state.Has(Hovered) || b.state.Has(Focused) {
+		p.Rect(r, ColorHighlight)
+	}
+	if b.state.Has(Pressed) {
+		p.Rect(r, ColorHighlight)
+	}
+}
+
+func (b *Base) textColor() Color {
+	if b.state.Has(Disabled) {
+		return ColorMuted
+	}
+	return ColorText
+}
@@ -0,0 +1,691 @@
+package ui
+
+import (
+	"testing"
+
+	"todo/shaping"
+)
+
+// Every rune as wide, in the order shown
+type fixedMetrics struct{}
+
+const runeWidth = 10
+
+func (fixedMetrics) Advance(text string, size Size) float64 {
+	return float64(len([]rune(text))) * runeWidth
+}
+
+func (fixedMetrics) CaretX(text string, caret int, size Size) float64 {
+	return float64(shaping.Shape(text).Caret(caret)) * runeWidth
+}
+
+// Records what was drawn
+type recorder struct {
+	fixedMetrics
+	rects []Rect
+	texts []string
+}
+
+func (r *recorder) Rect(rect Rect, c Color) {
+	r.rects = append(r.rects, rect)
+}
+
+func (r *recorder) Frame(rect Rect, c Color) {}
+
+func (r *recorder) Text(rect Rect, text string, size Size, align Align, c Color) {
+	r.texts = append(r.texts, text)
+}
+
+func (r *recorder) Icon(rect Rect, icon Icon, c Color) {}
+
+func hover(x, y float64) *Input {
+	return &Input{X: x, Y: y, Metrics: fixedMetrics{}}
+}
+
+func press(x, y float64) *Input {
+	return &Input{X: x, Y: y, Pressed: true, Down: true, Metrics: fixedMetrics{}}
+}
+
+func hold(x, y float64) *Input {
+	return &Input{X: x, Y: y, Down: true, Metrics: fixedMetrics{}}
+}
+
+func keys(k ...Key) *Input {
+	return &Input{X: -1, Y: -1, Keys: k, Metrics: fixedMetrics{}}
+}
+
+func typed(text string) *Input {
+	return &Input{X: -1, Y: -1, Chars: []rune(text), Metrics: fixedMetrics{}}
+}
+
+// Pressed then released at the same place
+func click(w Widget, x, y float64) {
+	w.Update(press(x, y))
+	w.Update(hover(x, y))
+}
+
+func TestRectContains(t *testing.T) {
+	r := Rect{10, 20, 30, 40}
+	cases := []struct {
+		x, y float64
+		in   bool
+	}{
+		{10, 20, true},
+		{40, 60, true},
+		{25, 40, true},
+		{9, 40, false},
+		{41, 40, false},
+		{25, 19, false},
+		{25, 61, false},
+	}
+	for _, c := range cases {
+		if got := r.Contains(c.x, c.y); got != c.in {
+			t.Errorf("Contains(%v, %v) = %v, want %v", c.x, c.y, got, c.in)
+		}
+	}
+}
+
+func TestButtonStates(t *testing.T) {
+	b := &Button{}
+	b.SetBounds(Rect{0, 0, 100, 30})
+
+	b.Update(hover(50, 15))
+	if !b.State().Has(Hovered) || b.State().Has(Pressed) {
+		t.Fatalf("hovered button has state %b", b.State())
+	}
+	b.Update(press(50, 15))
+	if !b.State().Has(Pressed) {
+		t.Fatalf("pressed button has state %b", b.State())
+	}
+	b.Update(hover(150, 15))
+	if b.State().Has(Hovered) || b.State().Has(Pressed) {
+		t.Fatalf("released outside button has state %b", b.State())
+	}
+}
+
+func TestButtonClick(t *testing.T) {
+	clicks := 0
+	b := &Button{OnClick: func() { clicks += 1 }}
+	b.SetBounds(Rect{0, 0, 100, 30})
+
+	click(b, 50, 15)
+	if clicks != 1 {
+		t.Fatalf("clicks = %d after a click, want 1", clicks)
+	}
+
+	// Held down, only the release counts
+	b.Update(press(50, 15))
+	b.Update(hold(60, 15))
+	b.Update(hold(60, 15))
+	if clicks != 1 {
+		t.Fatalf("clicks = %d while held, want 1", clicks)
+	}
+	b.Update(hover(60, 15))
+	if clicks != 2 {
+		t.Fatalf("clicks = %d after the release, want 2", clicks)
+	}
+
+	// Dragged out before the release
+	b.Update(press(50, 15))
+	b.Update(hold(150, 15))
+	b.Update(hover(150, 15))
+	if clicks != 2 {
+		t.Fatalf("clicks = %d after releasing outside, want 2", clicks)
+	}
+
+	// Pressed outside and released over it
+	b.Update(press(150, 15))
+	b.Update(hold(50, 15))
+	b.Update(hover(50, 15))
+	if clicks != 2 {
+		t.Fatalf("clicks = %d after pressing outside, want 2", clicks)
+	}
+}
+
+func TestDisabledIgnoresInput(t *testing.T) {
+	clicks := 0
+	b := &Button{OnClick: func() { clicks += 1 }}
+	b.SetBounds(Rect{0, 0, 100, 30})
+	b.Update(hover(50, 15))
+	b.SetDisabled(true)
+	if b.State().Has(Hovered) {
+		t.Fatalf("disabled button is still hovered")
+	}
+
+	click(b, 50, 15)
+	if clicks != 0 || b.State().Has(Hovered) {
+		t.Fatalf("disabled button clicked %d times, state %b", clicks, b.State())
+	}
+
+	b.SetDisabled(false)
+	click(b, 50, 15)
+	if clicks != 1 {
+		t.Fatalf("clicks = %d once enabled, want 1", clicks)
+	}
+}
+
+func TestCheckbox(t *testing.T) {
+	value := false
+	changes := 0
+	c := &Checkbox{Value: &value, BoxSize: 10, OnChange: func(bool) { changes += 1 }}
+	c.SetBounds(Rect{0, 0, 200, 30})
+
+	// Anywhere on the row
+	click(c, 20, 15)
+	if !value || changes != 1 {
+		t.Fatalf("value = %v after %d changes, want true after 1", value, changes)
+	}
+	click(c, 195, 15)
+	if value || changes != 2 {
+		t.Fatalf("value = %v after %d changes, want false after 2", value, changes)
+	}
+	click(c, 250, 15)
+	if value || changes != 2 {
+		t.Fatalf("click outside changed the value")
+	}
+	if box := c.box(); box != (Rect{190, 10, 10, 10}) {
+		t.Fatalf("box = %v, want on the right and centered", box)
+	}
+}
+
+func TestStepperClamp(t *testing.T) {
+	value := 9
+	var last int
+	s := &Stepper{Value: &value, Min: 1, Max: 10, ArrowWidth: 10, OnChange: func(v int) { last = v }}
+	s.SetBounds(Rect{0, 0, 100, 30})
+
+	click(s, 95, 15)
+	if value != 10 || last != 10 {
+		t.Fatalf("value = %d, want 10", value)
+	}
+	click(s, 95, 15)
+	if value != 10 {
+		t.Fatalf("value = %d past the max, want 10", value)
+	}
+	// The value itself does nothing
+	click(s, 50, 15)
+	if value != 10 {
+		t.Fatalf("value = %d after clicking the text, want 10", value)
+	}
+
+	s.Step = 4
+	click(s, 5, 15)
+	click(s, 5, 15)
+	click(s, 5, 15)
+	if value != 1 {
+		t.Fatalf("value = %d past the min, want 1", value)
+	}
+	if s.Set(1) {
+		t.Fatalf("setting the same value reported a change")
+	}
+}
+
+func TestStepperWrap(t *testing.T) {
+	value := 0
+	options := []string{"a", "b", "c"}
+	s := &Stepper{
+		Value: &value, Max: len(options) - 1, Wrap: true, ArrowWidth: 10,
+		Format: func(v int) string { return options[v] },
+	}
+	s.SetBounds(Rect{0, 0, 100, 30})
+
+	click(s, 5, 15)
+	if value != 2 || s.Text() != "c" {
+		t.Fatalf("value = %d (%q) going back from the first, want 2 (c)", value, s.Text())
+	}
+	click(s, 95, 15)
+	if value != 0 || s.Text() != "a" {
+		t.Fatalf("value = %d (%q) going past the last, want 0 (a)", value, s.Text())
+	}
+}
+
+func TestStepperHover(t *testing.T) {
+	value := 5
+	s := &Stepper{Value: &value, Min: 0, Max: 10, ArrowWidth: 10}
+	s.SetBounds(Rect{0, 0, 100, 30})
+
+	if !s.Update(hover(5, 15)) {
+		t.Fatalf("hovering an arrow didn't ask for a redraw")
+	}
+	if s.Update(hover(6, 15)) {
+		t.Fatalf("moving over the same arrow asked for a redraw")
+	}
+	if !s.Update(hover(95, 15)) {
+		t.Fatalf("hovering the other arrow didn't ask for a redraw")
+	}
+}
+
+func TestTextInput(t *testing.T) {
+	var changed string
+	submitted := ""
+	in := &TextInput{
+		MaxLength: 5,
+		OnChange:  func(text string) { changed = text },
+		OnSubmit:  func(text string) { submitted = text },
+	}
+	in.SetBounds(Rect{0, 0, 100, 30})
+
+	in.Update(typed("abc"))
+	if in.Text() != "" {
+		t.Fatalf("typed without the focus: %q", in.Text())
+	}
+
+	click(in, 50, 15)
+	if !in.State().Has(Focused) {
+		t.Fatalf("clicked input isn't focused")
+	}
+	in.Update(typed("abc"))
+	if in.Text() != "abc" || changed != "abc" || in.Caret() != 3 {
+		t.Fatalf("text = %q, caret %d after typing, want abc, 3", in.Text(), in.Caret())
+	}
+
+	in.Update(keys(KeyLeft, KeyLeft))
+	in.Update(typed("x"))
+	if in.Text() != "axbc" || in.Caret() != 2 {
+		t.Fatalf("text = %q, caret %d after inserting, want axbc, 2", in.Text(), in.Caret())
+	}
+	in.Update(keys(KeyBackspace))
+	if in.Text() != "abc" || changed != "abc" || in.Caret() != 1 {
+		t.Fatalf("text = %q, caret %d after backspace, want abc, 1", in.Text(), in.Caret())
+	}
+	in.Update(keys(KeyDelete))
+	if in.Text() != "ac" || in.Caret() != 1 {
+		t.Fatalf("text = %q, caret %d after delete, want ac, 1", in.Text(), in.Caret())
+	}
+	in.Update(keys(KeyHome))
+	in.Update(keys(KeyLeft))
+	if in.Caret() != 0 {
+		t.Fatalf("caret = %d before the start, want 0", in.Caret())
+	}
+	in.Update(keys(KeyEnd))
+	if in.Caret() != 2 {
+		t.Fatalf("caret = %d at the end, want 2", in.Caret())
+	}
+
+	in.Update(typed("defgh"))
+	if in.Text() != "acdef" {
+		t.Fatalf("text = %q past the max length, want acdef", in.Text())
+	}
+	in.Update(keys(KeyEnter))
+	if submitted != "acdef" {
+		t.Fatalf("submitted %q, want acdef", submitted)
+	}
+
+	in.Update(press(150, 15))
+	if in.State().Has(Focused) {
+		t.Fatalf("input still focused after a press elsewhere")
+	}
+}
+
+func TestTextInputRightToLeft(t *testing.T) {
+	in := &TextInput{}
+	in.SetBounds(Rect{0, 0, 100, 30})
+	in.SetText("שלום")
+	in.SetState(Focused, true)
+
+	// The end of the text is shown on the left
+	in.Update(keys(KeyHome))
+	if in.Caret() != 4 {
+		t.Fatalf("caret = %d on the left, want 4", in.Caret())
+	}
+	in.Update(keys(KeyRight))
+	if in.Caret() != 3 {
+		t.Fatalf("caret = %d moving right, want 3", in.Caret())
+	}
+	in.Update(keys(KeyEnd))
+	if in.Caret() != 0 {
+		t.Fatalf("caret = %d on the right, want 0", in.Caret())
+	}
+}
+
+func TestRootFocus(t *testing.T) {
+	var root Root
+	a, b, c := &TextInput{}, &TextInput{}, &TextInput{}
+	a.SetBounds(Rect{0, 0, 100, 30})
+	b.SetBounds(Rect{0, 40, 100, 30})
+	c.SetBounds(Rect{0, 80, 100, 30})
+	c.SetDisabled(true)
+	root.Add(a)
+	root.Add(&Button{})
+	root.Add(b)
+	root.Add(c)
+
+	click(&root, 50, 15)
+	if root.Focused() != Widget(a) {
+		t.Fatalf("clicked input isn't the focused one")
+	}
+	click(&root, 50, 55)
+	if root.Focused() != Widget(b) || a.State().Has(Focused) {
+		t.Fatalf("focus didn't move to the clicked input")
+	}
+
+	// The disabled one is skipped
+	root.Update(keys(KeyTab))
+	if root.Focused() != Widget(a) || b.State().Has(Focused) {
+		t.Fatalf("tab didn't cycle back to the first input")
+	}
+	root.Update(keys(KeyTab))
+	if root.Focused() != Widget(b) {
+		t.Fatalf("tab didn't move to the next input")
+	}
+
+	root.Blur()
+	if root.Focused() != nil || b.State().Has(Focused) {
+		t.Fatalf("blurred root still has a focus")
+	}
+}
+
+func TestGroupSkipsHidden(t *testing.T) {
+	clicks := 0
+	var g Group
+	hidden := &Button{OnClick: func() { clicks += 1 }}
+	hidden.SetBounds(Rect{0, 0, 100, 30})
+	hidden.SetHidden(true)
+	g.Add(hidden)
+	g.Add(&Label{Text: "label"})
+
+	click(&g, 50, 15)
+	if clicks != 0 {
+		t.Fatalf("hidden button was clicked")
+	}
+	r := &recorder{}
+	g.Draw(r)
+	if len(r.texts) != 1 || r.texts[0] != "label" {
+		t.Fatalf("drew %q, want only the label", r.texts)
+	}
+}
+
+func TestGroupLayout(t *testing.T) {
+	left, right := &Label{}, &Label{}
+	g := &Group{Layout: func(r Rect) {
+		left.SetBounds(Rect{r.X, r.Y, r.Width / 2, r.Height})
+		right.SetBounds(Rect{r.X + r.Width/2, r.Y, r.Width / 2, r.Height})
+	}}
+	g.Add(left)
+	g.Add(right)
+	g.SetBounds(Rect{10, 10, 100, 20})
+	if right.Bounds() != (Rect{60, 10, 50, 20}) {
+		t.Fatalf("right bounds = %v, want the right half", right.Bounds())
+	}
+}
+
+func newTestList(rows int) (*List, []int) {
+	l := &List{RowHeight: 10, ScrollStep: 10}
+	l.SetBounds(Rect{0, 0, 100, 35})
+	clicked := make([]int, rows)
+	for i := 0; i < rows; i += 1 {
+		row := i
+		l.Add(&Button{OnClick: func() { clicked[row] += 1 }})
+	}
+	return l, clicked
+}
+
+func TestListRowAt(t *testing.T) {
+	l, _ := newTestList(10)
+	cases := []struct {
+		y   float64
+		row int
+	}{
+		{0, 0},
+		{9, 0},
+		{15, 1},
+		{34, 3},
+		{-1, -1},
+		{36, -1},
+	}
+	for _, c := range cases {
+		if got := l.RowAt(50, c.y); got != c.row {
+			t.Errorf("RowAt(50, %v) = %d, want %d", c.y, got, c.row)
+		}
+	}
+	if got := l.RowAt(150, 15); got != -1 {
+		t.Errorf("RowAt outside = %d, want -1", got)
+	}
+
+	l.Scroll = 20
+	if got := l.RowAt(50, 15); got != 3 {
+		t.Errorf("RowAt(50, 15) scrolled = %d, want 3", got)
+	}
+}
+
+func TestListScroll(t *testing.T) {
+	l, clicked := newTestList(10)
+
+	in := hover(50, 15)
+	in.Wheel = -1
+	if !l.Update(in) || l.Scroll != 10 {
+		t.Fatalf("scroll = %v after the wheel, want 10", l.Scroll)
+	}
+	click(l, 50, 15)
+	if clicked[2] != 1 || clicked[1] != 0 {
+		t.Fatalf("clicked %v, want the third row", clicked)
+	}
+
+	// Not past the last row
+	in.Wheel = -20
+	l.Update(in)
+	if l.Scroll != 65 {
+		t.Fatalf("scroll = %v, want 65", l.Scroll)
+	}
+	in.Wheel = 20
+	l.Update(in)
+	if l.Scroll != 0 {
+		t.Fatalf("scroll = %v, want 0", l.Scroll)
+	}
+
+	// Partly shown rows are neither drawn nor clicked
+	click(l, 50, 33)
+	if clicked[3] != 0 {
+		t.Fatalf("clicked the partly shown row")
+	}
+	r := &recorder{}
+	l.Draw(r)
+	if len(r.texts) != 3 {
+		t.Fatalf("drew %d rows, want 3", len(r.texts))
+	}
+}
+
+func TestListOutsideBounds(t *testing.T) {
+	l, clicked := newTestList(10)
+	// Row 5 sits under the list, where nothing is shown
+	click(l, 50, 55)
+	if clicked[5] != 0 {
+		t.Fatalf("clicked a row outside of the list")
+	}
+}
+
+func newNumberStepper(value *int) *Stepper {
+	s := &Stepper{Value: value, Min: 1, Max: 999, ShiftStep: 10, Editable: true, Wheel: true, ArrowWidth: 10}
+	s.SetBounds(Rect{0, 0, 100, 30})
+	return s
+}
+
+func TestStepperShiftClick(t *testing.T) {
+	value := 25
+	s := newNumberStepper(&value)
+	in := press(95, 15)
+	in.Shift = true
+	s.Update(in)
+	s.Update(hover(95, 15))
+	if value != 35 {
+		t.Fatalf("value = %d after a shift click, want 35", value)
+	}
+	in = press(5, 15)
+	in.Shift = true
+	s.Update(in)
+	s.Update(hover(5, 15))
+	s.Update(in)
+	s.Update(hover(5, 15))
+	s.Update(in)
+	if value != 5 {
+		t.Fatalf("value = %d after three shift clicks down, want 5", value)
+	}
+	s.Update(hover(5, 15))
+	s.Update(in)
+	if value != 1 {
+		t.Fatalf("value = %d past the min, want 1", value)
+	}
+}
+
+func TestStepperHold(t *testing.T) {
+	value := 1
+	s := newNumberStepper(&value)
+	s.Update(press(95, 15))
+	if value != 2 {
+		t.Fatalf("value = %d once pressed, want 2", value)
+	}
+	for i := 1; i < holdDelay-1; i += 1 {
+		s.Update(hold(95, 15))
+	}
+	if value != 2 {
+		t.Fatalf("value = %d before the delay, want 2", value)
+	}
+	s.Update(hold(95, 15))
+	if value != 3 {
+		t.Fatalf("value = %d after the delay, want 3", value)
+	}
+	for i := 0; i < holdInterval; i += 1 {
+		s.Update(hold(95, 15))
+	}
+	if value != 4 {
+		t.Fatalf("value = %d after an interval, want 4", value)
+	}
+
+	// Faster once held long enough
+	for s.held < holdFast-1 {
+		s.Update(hold(95, 15))
+	}
+	before := value
+	for i := 0; i < holdInterval; i += 1 {
+		s.Update(hold(95, 15))
+	}
+	if value != before+holdFastStep {
+		t.Fatalf("value = %d after a fast interval, want %d", value, before+holdFastStep)
+	}
+
+	// Moving off the arrow stops it
+	before = value
+	for i := 0; i < holdDelay*2; i += 1 {
+		s.Update(hold(50, 15))
+	}
+	if value != before {
+		t.Fatalf("value = %d held off the arrow, want %d", value, before)
+	}
+	s.Update(hover(50, 15))
+}
+
+func TestStepperWheel(t *testing.T) {
+	value := 10
+	s := newNumberStepper(&value)
+	in := hover(50, 15)
+	in.Wheel = 1
+	s.Update(in)
+	in.Wheel = 1
+	s.Update(in)
+	if value != 12 {
+		t.Fatalf("value = %d after scrolling up, want 12", value)
+	}
+	in.Wheel = -1
+	s.Update(in)
+	if value != 11 {
+		t.Fatalf("value = %d after scrolling down, want 11", value)
+	}
+
+	in = hover(150, 15)
+	in.Wheel = 1
+	s.Update(in)
+	if value != 11 {
+		t.Fatalf("value = %d scrolling outside, want 11", value)
+	}
+
+	s.Wheel = false
+	in = hover(50, 15)
+	in.Wheel = 1
+	s.Update(in)
+	if value != 11 {
+		t.Fatalf("value = %d with the wheel off, want 11", value)
+	}
+}
+
+func TestStepperTyping(t *testing.T) {
+	value := 25
+	s := newNumberStepper(&value)
+
+	// Not on the arrows
+	click(s, 5, 15)
+	if s.Editing() {
+		t.Fatalf("editing after clicking an arrow")
+	}
+	value = 25
+	click(s, 50, 15)
+	if !s.Editing() || !s.State().Has(Focused) {
+		t.Fatalf("not editing after clicking the value")
+	}
+	s.Update(typed("4x5"))
+	if value != 25 {
+		t.Fatalf("value = %d while typing, want 25", value)
+	}
+	s.Update(keys(KeyEnter))
+	if value != 45 || s.Editing() || s.State().Has(Focused) {
+		t.Fatalf("value = %d after enter, want 45 and done editing", value)
+	}
+
+	// Clamped, and set by a click elsewhere
+	click(s, 50, 15)
+	s.Update(typed("0"))
+	s.Update(press(150, 15))
+	if value != 1 || s.Editing() {
+		t.Fatalf("value = %d after typing 0, want 1", value)
+	}
+
+	// At most the digits of the max
+	click(s, 50, 15)
+	s.Update(typed("12345"))
+	s.Update(keys(KeyBackspace))
+	s.SetState(Focused, false)
+	if value != 12 {
+		t.Fatalf("value = %d, want 12", value)
+	}
+
+	// Nothing typed keeps the value
+	click(s, 50, 15)
+	s.Update(keys(KeyEnter))
+	if value != 12 {
+		t.Fatalf("value = %d after an empty edit, want 12", value)
+	}
+
+	// The arrows go from the typed value
+	click(s, 50, 15)
+	s.Update(typed("30"))
+	click(s, 95, 15)
+	if value != 31 {
+		t.Fatalf("value = %d after typing then clicking up, want 31", value)
+	}
+
+	click(s, 50, 15)
+	s.Update(keys(KeyUp, KeyUp, KeyDown, KeyUp))
+	if value != 33 {
+		t.Fatalf("value = %d after the arrow keys, want 33", value)
+	}
+}
+
+func TestStepperTabFocus(t *testing.T) {
+	var root Root
+	value := 5
+	name := &TextInput{}
+	name.SetBounds(Rect{0, 40, 100, 30})
+	s := newNumberStepper(&value)
+	root.Add(name)
+	root.Add(s)
+
+	click(&root, 50, 55)
+	root.Update(keys(KeyTab))
+	if root.Focused() != Widget(s) || !s.Editing() {
+		t.Fatalf("tab didn't move to the stepper")
+	}
+	root.Update(typed("7"))
+	root.Update(keys(KeyTab))
+	if value != 7 || s.Editing() {
+		t.Fatalf("value = %d after tabbing away, want 7", value)
+	}
+}
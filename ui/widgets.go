@@ -0,0 +1,525 @@
+package ui
+
+import (
+	"strconv"
+
+	"todo/shaping"
+)
+
+type Label struct {
+	Base
+	Text  string
+	Size  Size
+	Align Align
+	Muted bool
+}
+
+func (l *Label) Update(in *Input) bool {
+	return false
+}
+
+func (l *Label) Draw(p Painter) {
+	c := l.textColor()
+	if l.Muted {
+		c = ColorMuted
+	}
+	p.Text(l.rect, l.Text, l.Size, l.Align, c)
+}
+
+// Framed unless flat, the flat ones looking like a label
+type Button struct {
+	Base
+	Text    string
+	Size    Size
+	Flat    bool
+	Muted   bool
+	OnClick func()
+}
+
+func (b *Button) Update(in *Input) bool {
+	if b.track(in, b.rect) && b.OnClick != nil {
+		b.OnClick()
+	}
+	return false
+}
+
+func (b *Button) Draw(p Painter) {
+	c := b.textColor()
+	if b.Muted {
+		c = ColorMuted
+	}
+	b.drawHighlight(p, b.rect)
+	if !b.Flat {
+		p.Frame(b.rect, c)
+	}
+	p.Text(b.rect, b.Text, b.Size, AlignCenter, c)
+}
+
+type IconButton struct {
+	Base
+	Icon    Icon
+	OnClick func()
+}
+
+func (b *IconButton) Update(in *Input) bool {
+	if b.track(in, b.rect) && b.OnClick != nil {
+		b.OnClick()
+	}
+	return false
+}
+
+func (b *IconButton) Draw(p Painter) {
+	b.drawHighlight(p, b.rect)
+	p.Frame(b.rect, b.textColor())
+	p.Icon(b.rect, b.Icon, b.textColor())
+}
+
+// The label on the left, the box on the right, the whole
+// width toggling the value
+type Checkbox struct {
+	Base
+	Label    string
+	Value    *bool
+	BoxSize  float64
+	OnChange func(checked bool)
+}
+
+func (c *Checkbox) box() Rect {
+	size := c.BoxSize
+	if size == 0 || size > c.rect.Height {
+		size = c.rect.Height
+	}
+	return Rect{c.rect.X + c.rect.Width - size, c.rect.Y + (c.rect.Height-size)/2, size, size}
+}
+
+func (c *Checkbox) Update(in *Input) bool {
+	if !c.track(in, c.rect) {
+		return false
+	}
+	*c.Value = !*c.Value
+	if c.OnChange != nil {
+		c.OnChange(*c.Value)
+	}
+	return true
+}
+
+func (c *Checkbox) Draw(p Painter) {
+	box := c.box()
+	c.drawHighlight(p, box)
+	p.Text(Rect{c.rect.X, c.rect.Y, box.X - c.rect.X, c.rect.Height}, c.Label, SizeNormal, AlignLeft, c.textColor())
+	p.Frame(box, c.textColor())
+	if *c.Value {
+		p.Rect(Rect{box.X + 3, box.Y + 3, box.Width - 6, box.Height - 6}, c.textColor())
+	}
+}
+
+const (
+	stepperNone = iota
+	stepperDec
+	stepperInc
+)
+
+// Held on an arrow, the value repeats after the delay and goes
+// faster the longer it is held, counted in updates
+const (
+	holdDelay    = 24
+	holdInterval = 4
+	holdFast     = 120
+	holdFastStep = 5
+)
+
+// A number changed with the arrows on each side. With Wrap, going
+// past one end comes back at the other, for picking in a list
+type Stepper struct {
+	Base
+	Value          *int
+	Min, Max, Step int
+	// Added instead of the step on a shift click, if any
+	ShiftStep int
+	Wrap      bool
+	// Typed in after clicking the value, and changed with the wheel
+	// when hovered. Both off for the lists, which scroll with the wheel
+	Editable bool
+	Wheel    bool
+	// The number by default
+	Format     func(v int) string
+	Size       Size
+	Framed     bool
+	ArrowWidth float64
+	OnChange   func(v int)
+
+	// The arrow under the mouse, and the one held down
+	part    int
+	holding int
+	held    int
+	// What was typed so far, nil when not editing
+	edit []rune
+}
+
+const defaultArrowWidth = 16
+
+func (s *Stepper) arrows() (dec, inc Rect) {
+	w := s.ArrowWidth
+	if w == 0 {
+		w = defaultArrowWidth
+	}
+	dec = Rect{s.rect.X, s.rect.Y, w, s.rect.Height}
+	inc = Rect{s.rect.X + s.rect.Width - w, s.rect.Y, w, s.rect.Height}
+	return dec, inc
+}
+
+func (s *Stepper) valueRect() Rect {
+	dec, inc := s.arrows()
+	return Rect{dec.X + dec.Width, s.rect.Y, inc.X - dec.X - dec.Width, s.rect.Height}
+}
+
+// Clamped or wrapped, calling back when it changed
+func (s *Stepper) Set(v int) bool {
+	switch {
+	case s.Wrap && v > s.Max:
+		v = s.Min
+	case s.Wrap && v < s.Min:
+		v = s.Max
+	case v > s.Max:
+		v = s.Max
+	case v < s.Min:
+		v = s.Min
+	}
+	if v == *s.Value {
+		return false
+	}
+	*s.Value = v
+	if s.OnChange != nil {
+		s.OnChange(v)
+	}
+	return true
+}
+
+func (s *Stepper) step() int {
+	if s.Step == 0 {
+		return 1
+	}
+	return s.Step
+}
+
+// Towards the arrow
+func (s *Stepper) move(part, amount int) bool {
+	if part == stepperDec {
+		amount = -amount
+	}
+	return s.Set(*s.Value + amount)
+}
+
+func (s *Stepper) focusable() bool {
+	return s.Editable
+}
+
+func (s *Stepper) Editing() bool {
+	return s.edit != nil
+}
+
+// Editing as long as it has the keyboard, losing it
+// keeping what was typed
+func (s *Stepper) SetState(flag State, on bool) {
+	s.Base.SetState(flag, on)
+	switch {
+	case s.state.Has(Focused) && s.edit == nil:
+		s.edit = []rune{}
+	case !s.state.Has(Focused) && s.edit != nil:
+		s.commit()
+	}
+}
+
+func (s *Stepper) commit() bool {
+	edit := s.edit
+	s.edit = nil
+	if len(edit) == 0 {
+		return false
+	}
+	v, err := strconv.Atoi(string(edit))
+	if err != nil {
+		return false
+	}
+	// Out of bounds, typed numbers are clamped even in a wrapping one
+	wrap := s.Wrap
+	s.Wrap = false
+	s.Set(v)
+	s.Wrap = wrap
+	return true
+}
+
+func (s *Stepper) Update(in *Input) bool {
+	dec, inc := s.arrows()
+	part := stepperNone
+	switch {
+	case !s.active():
+	case dec.Contains(in.X, in.Y):
+		part = stepperDec
+	case inc.Contains(in.X, in.Y):
+		part = stepperInc
+	}
+	changed := part != s.part
+	s.part = part
+	// First, for a click on an arrow to go from the typed value
+	if s.Editable {
+		changed = s.updateEdit(in) || changed
+	}
+
+	s.track(in, s.rect)
+	if in.Pressed && part != stepperNone {
+		amount := s.step()
+		if in.Shift && s.ShiftStep != 0 {
+			amount = s.ShiftStep
+		}
+		changed = s.move(part, amount) || changed
+		s.holding = part
+		s.held = 0
+	}
+	if s.state.Has(Pressed) && s.holding != stepperNone && s.holding == part {
+		s.held += 1
+		if s.held >= holdDelay && (s.held-holdDelay)%holdInterval == 0 {
+			amount := s.step()
+			if s.held >= holdFast {
+				amount *= holdFastStep
+			}
+			changed = s.move(part, amount) || changed
+		}
+	} else {
+		s.holding = stepperNone
+	}
+
+	if s.Wheel && s.state.Has(Hovered) && in.Wheel != 0 {
+		part := stepperInc
+		if in.Wheel < 0 {
+			part = stepperDec
+		}
+		changed = s.move(part, s.step()) || changed
+	}
+	return changed
+}
+
+// Started by a click on the value, the digits replacing it and
+// enter or a click somewhere else setting it
+func (s *Stepper) updateEdit(in *Input) bool {
+	wasEditing := s.edit != nil
+	if in.Pressed {
+		s.Base.SetState(Focused, s.active() && s.valueRect().Contains(in.X, in.Y))
+	}
+	if !s.state.Has(Focused) {
+		if s.edit != nil {
+			s.commit()
+		}
+		return wasEditing
+	}
+	if s.edit == nil {
+		s.edit = []rune{}
+	}
+
+	changed := !wasEditing
+	maxDigits := len(strconv.Itoa(s.Max))
+	for _, r := range in.Chars {
+		if r >= '0' && r <= '9' && len(s.edit) < maxDigits {
+			s.edit = append(s.edit, r)
+			changed = true
+		}
+	}
+	for _, key := range in.Keys {
+		switch key {
+		case KeyBackspace:
+			if len(s.edit) > 0 {
+				s.edit = s.edit[:len(s.edit)-1]
+				changed = true
+			}
+		case KeyUp:
+			s.edit = s.edit[:0]
+			changed = s.move(stepperInc, s.step()) || changed
+		case KeyDown:
+			s.edit = s.edit[:0]
+			changed = s.move(stepperDec, s.step()) || changed
+		case KeyEnter:
+			s.SetState(Focused, false)
+			return true
+		}
+	}
+	return changed
+}
+
+func (s *Stepper) Text() string {
+	if s.Format != nil {
+		return s.Format(*s.Value)
+	}
+	return strconv.Itoa(*s.Value)
+}
+
+func (s *Stepper) Draw(p Painter) {
+	dec, inc := s.arrows()
+	switch s.part {
+	case stepperDec:
+		s.drawHighlight(p, dec)
+	case stepperInc:
+		s.drawHighlight(p, inc)
+	}
+	if s.Framed {
+		p.Frame(s.rect, s.textColor())
+	}
+	p.Text(dec, "<", SizeNormal, AlignCenter, ColorMuted)
+	p.Text(inc, ">", SizeNormal, AlignCenter, ColorMuted)
+	value := s.valueRect()
+	if s.edit == nil {
+		p.Text(value, s.Text(), s.Size, AlignCenter, s.textColor())
+		return
+	}
+
+	// The current value stays until something is typed
+	text, c := string(s.edit), ColorText
+	if len(s.edit) == 0 {
+		text, c = strconv.Itoa(*s.Value), ColorMuted
+	}
+	p.Rect(value, ColorHighlight)
+	p.Text(value, text, s.Size, AlignCenter, c)
+	x := value.X + (value.Width+p.Advance(text, s.Size))/2
+	p.Rect(Rect{x + 1, value.Y + 5, 2, value.Height - 10}, ColorText)
+}
+
+// Single line of text edited with the keyboard once clicked.
+// The caret is kept in logical order and moved with the arrows
+// in the order the text is shown
+type TextInput struct {
+	Base
+	Placeholder string
+	MaxLength   int
+	Size        Size
+	OnChange    func(text string)
+	OnSubmit    func(text string)
+
+	text  []rune
+	caret int
+}
+
+const textInputPadding = 2
+
+func (t *TextInput) focusable() bool {
+	return true
+}
+
+func (t *TextInput) Text() string {
+	return string(t.text)
+}
+
+func (t *TextInput) SetText(text string) {
+	t.text = []rune(text)
+	t.caret = len(t.text)
+}
+
+func (t *TextInput) Caret() int {
+	return t.caret
+}
+
+func (t *TextInput) Update(in *Input) bool {
+	t.track(in, t.rect)
+	t.trackFocus(in)
+	if !t.state.Has(Focused) {
+		return false
+	}
+
+	edited := false
+	for _, r := range in.Chars {
+		if t.MaxLength > 0 && len(t.text) >= t.MaxLength {
+			break
+		}
+		t.text = append(t.text, 0)
+		copy(t.text[t.caret+1:], t.text[t.caret:])
+		t.text[t.caret] = r
+		t.caret += 1
+		edited = true
+	}
+	moved := false
+	for _, key := range in.Keys {
+		switch key {
+		case KeyBackspace:
+			if t.caret > 0 {
+				t.text = append(t.text[:t.caret-1], t.text[t.caret:]...)
+				t.caret -= 1
+				edited = true
+			}
+		case KeyDelete:
+			if t.caret < len(t.text) {
+				t.text = append(t.text[:t.caret], t.text[t.caret+1:]...)
+				edited = true
+			}
+		case KeyLeft:
+			moved = t.moveCaret(in.Metrics, -1) || moved
+		case KeyRight:
+			moved = t.moveCaret(in.Metrics, 1) || moved
+		case KeyHome, KeyEnd:
+			t.caret = t.visualEnd(key == KeyEnd)
+			moved = true
+		case KeyEnter:
+			if t.OnSubmit != nil {
+				t.OnSubmit(string(t.text))
+			}
+		}
+	}
+	if edited && t.OnChange != nil {
+		t.OnChange(string(t.text))
+	}
+	return edited || moved
+}
+
+// To the closest caret position on that side, on screen
+func (t *TextInput) moveCaret(m Metrics, dir float64) bool {
+	if m == nil {
+		return false
+	}
+	text := string(t.text)
+	current := m.CaretX(text, t.caret, t.Size)
+	next := -1
+	nextX := 0.0
+	for i := 0; i <= len(t.text); i += 1 {
+		x := m.CaretX(text, i, t.Size)
+		if (x-current)*dir <= 0 {
+			continue
+		}
+		if next == -1 || (x-nextX)*dir < 0 {
+			next = i
+			nextX = x
+		}
+	}
+	if next == -1 {
+		return false
+	}
+	t.caret = next
+	return true
+}
+
+// The logical position shown at the right or the left end
+func (t *TextInput) visualEnd(right bool) int {
+	layout := shaping.Shape(string(t.text))
+	end := 0
+	if right {
+		end = len(layout.Visual)
+	}
+	for i := 0; i <= len(t.text); i += 1 {
+		if layout.Caret(i) == end {
+			return i
+		}
+	}
+	return t.caret
+}
+
+func (t *TextInput) Draw(p Painter) {
+	p.Frame(t.rect, t.textColor())
+	if t.state.Has(Focused) {
+		p.Rect(t.rect, ColorHighlight)
+	}
+	inside := Rect{t.rect.X + textInputPadding, t.rect.Y, t.rect.Width - textInputPadding*2, t.rect.Height}
+	if len(t.text) > 0 {
+		p.Text(inside, string(t.text), t.Size, AlignLeft, t.textColor())
+	} else if !t.state.Has(Focused) {
+		p.Text(inside, t.Placeholder, t.Size, AlignLeft, ColorMuted)
+	}
+	if t.state.Has(Focused) {
+		x := inside.X + p.CaretX(string(t.text), t.caret, t.Size)
+		p.Rect(Rect{x, t.rect.Y + 5, 2, t.rect.Height - 10}, ColorText)
+	}
+}
@@ -3,13 +3,14 @@ package main
 import (
 	"image"
 	_ "image/png"
-	"os"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
 )
 
 type (
@@ -51,8 +52,7 @@ type (
 )
 
 var (
-	White     = Color{255, 255, 255, 255}
-	WhiteA125 = Color{255, 255, 255, 125}
+	White = Color{255, 255, 255, 255}
 	// Black     = Color{0, 0, 0, 255}
 )
 
@@ -75,6 +75,13 @@ func (r rectangle) addPoint(p point) rectangle {
 	}
 }
 
+func (r rectangle) scale(s float64) rectangle {
+	return rectangle{
+		x: r.x * s, y: r.y * s,
+		width: r.width * s, height: r.height * s,
+	}
+}
+
 func (r rectangle) boundCheck(p point) bool {
 	return (p[0] >= r.x && p[0] <= r.x+r.width) && (p[1] >= r.y && p[1] <= r.y+r.height)
 }
@@ -83,25 +90,40 @@ func (p point) sub(p2 point) point {
 	return point{p[0] - p2[0], p[1] - p2[1]}
 }
 
+func (p point) add(p2 point) point {
+	return point{p[0] + p2[0], p[1] + p2[1]}
+}
+
+// The faces are rasterized for the current UI scale,
+// but all the metrics are given back in unscaled units
 type Font struct {
 	faces map[int]font.Face
+	name  string
+	sizes []int
+	scale float64
+
+	// For the glyphs the main font doesn't have
+	fallbackFaces map[int][]font.Face
+	buf           sfnt.Buffer
 }
 
-func NewFont(path string, dpi float64, sizes []int) Font {
+// The font data comes from the assets, shared by all the fonts
+func NewFont(name string, dpi float64, sizes []int) Font {
 	f := Font{
-		faces: make(map[int]font.Face, len(sizes)),
-	}
-
-	fontData, err := os.ReadFile(path)
-	if err != nil {
-		panic(err)
-	}
-	tt, err := opentype.Parse(fontData)
-	if err != nil {
-		panic(err)
+		name:  name,
+		sizes: sizes,
 	}
+	f.setDPI(dpi)
+	return f
+}
 
-	for _, v := range sizes {
+// Generates all the faces again, called when the UI scale changes
+func (f *Font) setDPI(dpi float64) {
+	f.faces = make(map[int]font.Face, len(f.sizes))
+	f.fallbackFaces = make(map[int][]font.Face, len(f.sizes))
+	f.scale = dpi / 72
+	tt := assets.font(f.name)
+	for _, v := range f.sizes {
 		face, err := opentype.NewFace(tt, &opentype.FaceOptions{
 			Size:    float64(v),
 			DPI:     dpi,
@@ -112,33 +134,56 @@ func NewFont(path string, dpi float64, sizes []int) Font {
 		}
 		f.faces[v] = face
 	}
-	return f
 }
 
 func (f *Font) GlyphAdvance(r rune, size float64) float64 {
-	x, _ := f.faces[int(size)].GlyphAdvance(r)
-	return float64(x>>6) + float64(x&((1<<6)-1))/float64(1<<6)
+	x, _ := f.faceFor(r, int(size)).GlyphAdvance(r)
+	return f.unscale(x)
 }
 
 func (f *Font) Ascent(size float64) float64 {
 	x := f.faces[int(size)].Metrics().Ascent
-	return float64(x>>6) + float64(x&((1<<6)-1))/float64(1<<6)
+	return f.unscale(x)
+}
+
+// Width of the text with the kerning, where a cursor
+// placed after it should be
+func (f *Font) Advance(t string, size float64) float64 {
+	x := fixed.Int26_6(0)
+	for _, run := range f.runs(t, size) {
+		x = run.x + font.MeasureString(run.face, run.text)
+	}
+	return f.unscale(x)
 }
 
 func (f *Font) MeasureText(t string, size float64) point {
 	measure := point{}
 
-	if v, exist := f.faces[int(size)]; !exist {
+	if _, exist := f.faces[int(size)]; !exist {
 		panic("No face of size in given Font")
-	} else {
-		r := text.BoundString(v, t)
-		measure[0] = float64(r.Dx())
-		measure[1] = float64(r.Dy())
 	}
+	bounds := image.Rectangle{}
+	for _, run := range f.runs(t, size) {
+		r := text.BoundString(run.face, run.text).Add(image.Pt(run.x.Round(), 0))
+		bounds = bounds.Union(r)
+	}
+	measure[0] = float64(bounds.Dx()) / f.scale
+	measure[1] = float64(bounds.Dy()) / f.scale
 
 	return measure
 }
 
+func (f *Font) unscale(x fixed.Int26_6) float64 {
+	return (float64(x>>6) + float64(x&((1<<6)-1))/float64(1<<6)) / f.scale
+}
+
+// Takes pixel positions, each run drawn with its own face
+func (f *Font) draw(dst *ebiten.Image, t string, size float64, x, y int, clr Color) {
+	for _, run := range f.runs(t, size) {
+		text.Draw(dst, run.text, run.face, x+run.x.Round(), y, clr)
+	}
+}
+
 type textOptions struct {
 	font   *Font
 	text   string
@@ -150,12 +195,12 @@ type textOptions struct {
 
 func drawText(dst *ebiten.Image, opt textOptions) {
 	ascent := opt.font.Ascent(opt.size)
-	text.Draw(
+	opt.font.draw(
 		dst,
 		opt.text,
-		opt.font.faces[int(opt.size)],
-		int(opt.pos[0]),
-		int(opt.pos[1]+ascent),
+		opt.size,
+		int(opt.pos[0]*uiScale),
+		int((opt.pos[1]+ascent)*uiScale),
 		opt.clr,
 	)
 }
@@ -169,19 +214,20 @@ func drawTextCenter(dst *ebiten.Image, opt textOptions) {
 		opt.bounds.x + (opt.bounds.width/2 - textWidth/2),
 		opt.bounds.y + (opt.bounds.height/2 - ascent/2) + yOffset,
 	}
-	text.Draw(
+	opt.font.draw(
 		dst,
 		opt.text,
-		opt.font.faces[int(opt.size)],
-		int(textPos[0]),
-		int(textPos[1]+ascent),
+		opt.size,
+		int(textPos[0]*uiScale),
+		int((textPos[1]+ascent)*uiScale),
 		opt.clr,
 	)
 }
 
 func drawImage(dst, src *ebiten.Image, p point, clr Color) {
 	opt := ebiten.DrawImageOptions{}
-	opt.GeoM.Translate(p[0], p[1])
+	opt.GeoM.Scale(uiScale, uiScale)
+	opt.GeoM.Translate(p[0]*uiScale, p[1]*uiScale)
 	opt.Filter = ebiten.FilterLinear
 	r, g, b, a := clr.RGBA()
 	opt.ColorM.Scale(
@@ -204,13 +250,14 @@ func drawImageCentered(dst, src *ebiten.Image, bounds rectangle, scale float64,
 		float64(a)/0xffff,
 	)
 
+	scale *= uiScale
 	opt.GeoM.Scale(scale, scale)
 	// Position
 	w := float64(src.Bounds().Dx()) * scale
 	h := float64(src.Bounds().Dy()) * scale
 	opt.GeoM.Translate(
-		bounds.x+(bounds.width/2-w/2),
-		bounds.y+(bounds.height/2-h/2),
+		(bounds.x+bounds.width/2)*uiScale-w/2,
+		(bounds.y+bounds.height/2)*uiScale-h/2,
 	)
 
 	dst.DrawImage(src, &opt)
@@ -241,12 +288,12 @@ func drawImageSlice(dst *ebiten.Image, dstRect rectangle, src *ebiten.Image, c c
 	dstU := u
 	dstD := d
 
-	// if scale > 0 {
-	// 	dstL *= scale
-	// 	dstR *= scale
-	// 	dstU *= scale
-	// 	dstD *= scale
-	// }
+	// The borders grow along with the UI
+	dstL *= uiScale
+	dstR *= uiScale
+	dstU *= uiScale
+	dstD *= uiScale
+	dstRect = dstRect.scale(uiScale)
 
 	dstX0 := dstRect.x
 	dstX1 := dstRect.x + dstL
@@ -311,16 +358,45 @@ func drawImageSlice(dst *ebiten.Image, dstRect rectangle, src *ebiten.Image, c c
 	}
 }
 
+// Only allocates a new image when the size changed,
+// the canvas having the pixel size of the rect
+func resizeCanvas(canvas *ebiten.Image, r rectangle) *ebiten.Image {
+	w, h := int(r.width*uiScale), int(r.height*uiScale)
+	if canvas != nil {
+		if size := canvas.Bounds().Size(); size.X == w && size.Y == h {
+			return canvas
+		}
+		canvas.Dispose()
+	}
+	return ebiten.NewImage(w, h)
+}
+
+// Canvases are already drawn at the UI scale
+func drawCanvas(dst, canvas *ebiten.Image, p point) {
+	opt := ebiten.DrawImageOptions{}
+	opt.GeoM.Translate(p[0]*uiScale, p[1]*uiScale)
+	dst.DrawImage(canvas, &opt)
+}
+
 func drawRect(dst *ebiten.Image, r rectangle, clr Color) {
+	r = r.scale(uiScale)
 	ebitenutil.DrawRect(dst, r.x, r.y, r.width, r.height, clr)
 }
 
+func drawLine(dst *ebiten.Image, x1, y1, x2, y2 float64, clr Color) {
+	ebitenutil.DrawLine(dst, x1*uiScale, y1*uiScale, x2*uiScale, y2*uiScale, clr)
+}
+
+// The time scale is sped up, a timer second only lasts a few ticks
+const timerTicksPerSecond = 5
+
 type (
 	minute  int
 	seconds int
 
 	timer struct {
 		running bool
+		countUp bool
 		min     minute
 		sec     seconds
 		timer   seconds
@@ -329,12 +405,39 @@ type (
 )
 
 func (t *timer) setDuration(m minute, s seconds) {
+	t.countUp = false
 	t.min = m
 	t.sec = s
 	t.timer = 0
 	t.updateString()
 }
 
+// The timer counts up from zero and never finishes
+func (t *timer) setCountUp() {
+	t.setDuration(0, 0)
+	t.countUp = true
+}
+
+func (t *timer) total() seconds {
+	return seconds(t.min)*60 + t.sec
+}
+
+// Moves the timer forward in time, negative values rewind it
+func (t *timer) skip(s seconds) {
+	total := seconds(t.min)*60 + t.sec
+	if t.countUp {
+		total += s
+	} else {
+		total -= s
+	}
+	if total < 0 {
+		total = 0
+	}
+	t.min = minute(total / 60)
+	t.sec = total % 60
+	t.updateString()
+}
+
 func (t *timer) start() {
 	t.running = true
 }
@@ -343,12 +446,19 @@ func (t *timer) stop() {
 	t.running = false
 }
 
-func (t *timer) advance() (finished bool) {
-	const tps = 5
-	finished = false
+func (t *timer) advance() (ticked, finished bool) {
 	if t.running {
 		t.timer += 1
-		if t.timer == tps {
+		if t.timer == timerTicksPerSecond && t.countUp {
+			t.timer = 0
+			t.sec += 1
+			if t.sec == 60 {
+				t.min += 1
+				t.sec = 0
+			}
+			ticked = true
+			t.updateString()
+		} else if t.timer == timerTicksPerSecond {
 			t.timer = 0
 			if t.sec == 0 {
 				if t.min == 0 && t.sec == 0 {
@@ -357,9 +467,11 @@ func (t *timer) advance() (finished bool) {
 				} else {
 					t.min -= 1
 					t.sec = 59
+					ticked = true
 				}
 			} else {
 				t.sec -= 1
+				ticked = true
 			}
 			t.updateString()
 
@@ -437,59 +549,6 @@ func numberToString(n int, buf []rune) (last int) {
 	return
 }
 
-////////////////
-////////////////
-////////////////
-const defaultTextBoxCap = 100
-
-type textBox struct {
-	font      *Font
-	fontSize  float64
-	charBuf   []rune
-	charCount int
-	cursor    rectangle
-}
-
-func (t *textBox) init(font *Font, fontSize float64) {
-	t.font = font
-	t.fontSize = fontSize
-	t.cursor = rectangle{
-		width:  2,
-		height: textSize,
-	}
-	t.charBuf = make([]rune, defaultTextBoxCap)
-}
-
-func (t *textBox) update() {
-
-}
-
-func (t *textBox) AppendChar(r rune) {
-	t.charBuf[t.charCount] = r
-	t.charCount += 1
-	t.cursor.x += t.font.GlyphAdvance(r, t.fontSize)
-}
-
-func (t *textBox) DeleteChar() {
-	if t.charCount > 0 {
-		r := t.charBuf[t.charCount-1]
-		t.charCount -= 1
-		t.cursor.x -= t.font.GlyphAdvance(r, t.fontSize)
-	}
-}
-
-func (t *textBox) Clear() {
-	t.cursor = rectangle{
-		width:  2,
-		height: textSize,
-	}
-	t.charCount = 0
-}
-
-func (t *textBox) GetText() []rune {
-	return t.charBuf[:t.charCount]
-}
-
 ////////////////
 ////////////////
 ////////////////
@@ -572,6 +631,15 @@ func (r *rectLayout) cut(cutKind rectCutKind, length float64, padding float64) r
 	return newRectLayout(result)
 }
 
+// Cuts both sides evenly, only leaving the given width in the middle
+func (r *rectLayout) keepCenter(width float64) {
+	side := (r.remaining.width - width) / 2
+	if side > 0 {
+		r.cut(rectCutLeft, side, 0)
+		r.cut(rectCutRight, side, 0)
+	}
+}
+
 ////////////////
 ////////////////
 ////////////////
@@ -629,6 +697,6 @@ func (r *rectArray) update(mPos point, mLeft bool) {
 
 func (r *rectArray) highlight(dst *ebiten.Image) {
 	if r.focus.active {
-		drawRect(dst, r.focus.rect.addPoint(r.offset), WhiteA125)
+		drawRect(dst, r.focus.rect.addPoint(r.offset), theme.Highlight)
 	}
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"todo/anim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	warningPulseCount = 3
+	// The last seconds of a phase drawn with the ramp colour
+	warningRampSeconds = 60
+	warningOutlineSize = 4
+)
+
+// Pulses the outline of the running timer a few times
+// when a phase is about to end
+type timerWarning struct {
+	taskID    int
+	pulse     anim.Animation
+	alpha     float64
+	remaining int
+}
+
+func (w *timerWarning) init() {
+	AddSignalListener(todoTaskWarning, w)
+
+	w.pulse = anim.NewAnimation("warningPulse", w)
+	w.pulse.AddProperty("alpha", &w.alpha, 0, true)
+	w.pulse.AddKey("alpha", anim.AnimationKey{
+		Easing:   anim.EaseOutCubic,
+		Duration: anim.SecondsToTicks(0.3),
+		Change:   1,
+	})
+	w.pulse.AddKey("alpha", anim.AnimationKey{
+		Easing:    anim.EaseInCubic,
+		StartTime: anim.SecondsToTicks(0.3),
+		Duration:  anim.SecondsToTicks(0.5),
+		Change:    -1,
+	})
+}
+
+func (w *timerWarning) update() {
+	w.pulse.Update()
+}
+
+// Only drawn for the task the warning was fired for
+func (w *timerWarning) draw(dst *ebiten.Image, t *task, rect rectangle) {
+	if !w.pulse.Playing || w.taskID != t.id {
+		return
+	}
+	clr := theme.Warning
+	clr[3] = uint8(255 * w.alpha)
+	rect.x -= warningOutlineSize
+	rect.y -= warningOutlineSize
+	rect.width += warningOutlineSize * 2
+	rect.height += warningOutlineSize * 2
+	drawImageSlice(dst, rect, rectOutline, rectConstraint, clr)
+}
+
+func (w *timerWarning) OnSignal(s Signal) {
+	switch s.Kind {
+	case todoTaskWarning:
+		if !userSettings.WarningPulse {
+			return
+		}
+		w.taskID = s.Value.(*task).id
+		w.remaining = warningPulseCount - 1
+		w.pulse.Reset()
+		w.pulse.Play()
+	}
+}
+
+func (w *timerWarning) OnAnimationEnd(name string) {
+	if w.remaining > 0 {
+		w.remaining -= 1
+		w.pulse.Play()
+	}
+}
+
+// The progress bar colour slowly moves toward the warning
+// accent during the last minute of the phase
+func progressColor(t *task) Color {
+	return rampColor(t, theme.Progress)
+}
+
+func rampColor(t *task, base Color) Color {
+	if !userSettings.WarningRamp || t.timer.countUp || t.overtime {
+		return base
+	}
+	left := t.timer.total()
+	if left > warningRampSeconds || t.phaseTotal <= warningRampSeconds {
+		return base
+	}
+	ratio := 1 - float64(left)/warningRampSeconds
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*ratio)
+	}
+	return Color{
+		lerp(base[0], theme.Warning[0]),
+		lerp(base[1], theme.Warning[1]),
+		lerp(base[2], theme.Warning[2]),
+		lerp(base[3], 200),
+	}
+}
@@ -1,49 +1,186 @@
 package main
 
-import "github.com/hajimehoshi/ebiten/v2"
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"todo/ui"
+)
 
 func drawTextBtn(dst *ebiten.Image, rect rectangle, text string, size float64) {
-	drawImageSlice(dst, rect, rectOutline, rectConstraint, White)
+	drawColoredTextBtn(dst, rect, text, size, theme.Text)
+}
+
+func drawColoredTextBtn(dst *ebiten.Image, rect rectangle, text string, size float64, clr Color) {
+	const btnTextMargin = 6
+
+	text, _ = defaultFont.truncate(text, size, rect.width-btnTextMargin*2)
+	drawImageSlice(dst, rect, rectOutline, rectConstraint, clr)
 	drawTextCenter(dst, textOptions{
 		font: &defaultFont, text: text, bounds: rect,
-		size: size, clr: White,
+		size: size, clr: clr,
 	})
 }
 
 func drawIcontBtn(dst *ebiten.Image, rect rectangle, icon *ebiten.Image) {
-	drawImageSlice(dst, rect, rectOutline, rectConstraint, White)
-	drawImageCentered(dst, icon, rect, 1, White)
+	drawImageSlice(dst, rect, rectOutline, rectConstraint, theme.Text)
+	drawImageCentered(dst, icon, rect, 1, theme.Text)
 }
 
-func drawSlider(dst *ebiten.Image, rect, incRect, decRect rectangle, t string) {
-	drawImageSlice(dst, rect, rectOutline, rectConstraint, White)
+// The value between the arrows, for text values
+func drawChoice(dst *ebiten.Image, rect, incRect, decRect rectangle, t string) {
+	drawImageSlice(dst, rect, rectOutline, rectConstraint, theme.Text)
 	drawTextCenter(dst, textOptions{
 		font: &defaultFont, text: "<", bounds: decRect,
-		size: textSize, clr: Color{255, 255, 255, 120},
+		size: textSize, clr: theme.MutedText,
 	})
 	drawTextCenter(dst, textOptions{
 		font: &defaultFont, text: ">", bounds: incRect,
-		size: textSize, clr: Color{255, 255, 255, 120},
+		size: textSize, clr: theme.MutedText,
 	})
-
-	// lSize := defaultFont.MeasureText(t, largeTextSize)[0]
-	// tSize := lSize + defaultFont.MeasureText("count", smallTextSize)[0]
-	// textPos := point{
-	// 	a.countRect.x + (a.countRect.width/2 - tSize/2),
-	// 	a.countRect.y + a.font.Ascent(largeTextSize)/2,
-	// }
-	// drawText(dst, textOptions{
-	// 	font: &defaultFont, text: txt, pos: textPos,
-	// 	size: largeTextSize, clr: White,
-	// })
-	// textPos[0] += lSize + 4
-	// textPos[1] += (defaultFont.Ascent(largeTextSize) - defaultFont.Ascent(smallTextSize))
-	// drawText(dst, textOptions{
-	// 	font: &defaultFont, text: "count", pos: textPos,
-	// 	size: smallTextSize, clr: Color{255, 255, 255, 120},
-	// })
 	drawTextCenter(dst, textOptions{
 		font: &defaultFont, text: t, bounds: rect,
-		size: largeTextSize, clr: White,
+		size: textSize, clr: theme.Text,
+	})
+}
+
+// Darkens everything behind a modal window
+func drawModalBackground(dst *ebiten.Image) {
+	clr := theme.Background3
+	clr[3] = 127
+	drawRect(dst, screenBounds, clr)
+}
+
+// Draws the ui widgets with the theme, moved by the origin
+// when not drawn on the canvas of their window
+type widgetPainter struct {
+	dst    *ebiten.Image
+	font   *Font
+	origin point
+}
+
+func uiRect(r rectangle) ui.Rect {
+	return ui.Rect{X: r.x, Y: r.y, Width: r.width, Height: r.height}
+}
+
+func fromUIRect(r ui.Rect) rectangle {
+	return rectangle{r.X, r.Y, r.Width, r.Height}
+}
+
+func uiTextSize(size ui.Size) float64 {
+	switch size {
+	case ui.SizeSmall:
+		return smallTextSize
+	case ui.SizeLarge:
+		return largeTextSize
+	}
+	return textSize
+}
+
+func uiColor(c ui.Color) Color {
+	switch c {
+	case ui.ColorMuted:
+		return theme.MutedText
+	case ui.ColorHighlight:
+		return theme.Highlight
+	case ui.ColorSeparator:
+		return theme.Separator
+	case ui.ColorBackground:
+		return theme.Background1
+	}
+	return theme.Text
+}
+
+func (p widgetPainter) bounds(r ui.Rect) rectangle {
+	return fromUIRect(r).addPoint(p.origin)
+}
+
+func (p widgetPainter) Advance(text string, size ui.Size) float64 {
+	return p.font.Advance(text, uiTextSize(size))
+}
+
+func (p widgetPainter) CaretX(text string, caret int, size ui.Size) float64 {
+	return p.font.caretX(text, caret, uiTextSize(size))
+}
+
+func (p widgetPainter) Rect(r ui.Rect, c ui.Color) {
+	drawRect(p.dst, p.bounds(r), uiColor(c))
+}
+
+func (p widgetPainter) Frame(r ui.Rect, c ui.Color) {
+	drawImageSlice(p.dst, p.bounds(r), rectOutline, rectConstraint, uiColor(c))
+}
+
+func (p widgetPainter) Text(r ui.Rect, text string, size ui.Size, align ui.Align, c ui.Color) {
+	const textMargin = 6
+
+	fontSize := uiTextSize(size)
+	if align == ui.AlignCenter {
+		text, _ = p.font.truncate(text, fontSize, r.Width-textMargin*2)
+		drawTextCenter(p.dst, textOptions{
+			font: p.font, text: text, bounds: p.bounds(r),
+			size: fontSize, clr: uiColor(c),
+		})
+		return
+	}
+	text, _ = p.font.truncate(text, fontSize, r.Width)
+	drawText(p.dst, textOptions{
+		font: p.font, text: text,
+		pos:  point{r.X, r.Y + (r.Height-p.font.Ascent(fontSize))/2}.add(p.origin),
+		size: fontSize, clr: uiColor(c),
 	})
 }
+
+func (p widgetPainter) Icon(r ui.Rect, icon ui.Icon, c ui.Color) {
+	if img, ok := icon.(*ebiten.Image); ok {
+		drawImageCentered(p.dst, img, p.bounds(r), 1, uiColor(c))
+	}
+}
+
+var uiKeys = []struct {
+	key    ebiten.Key
+	uiKey  ui.Key
+	repeat bool
+}{
+	{ebiten.KeyBackspace, ui.KeyBackspace, true},
+	{ebiten.KeyDelete, ui.KeyDelete, true},
+	{ebiten.KeyArrowLeft, ui.KeyLeft, true},
+	{ebiten.KeyArrowRight, ui.KeyRight, true},
+	{ebiten.KeyArrowUp, ui.KeyUp, true},
+	{ebiten.KeyArrowDown, ui.KeyDown, true},
+	{ebiten.KeyHome, ui.KeyHome, false},
+	{ebiten.KeyEnd, ui.KeyEnd, false},
+	{ebiten.KeyEnter, ui.KeyEnter, false},
+	{ebiten.KeyEscape, ui.KeyEscape, false},
+	{ebiten.KeyTab, ui.KeyTab, false},
+}
+
+// Held keys repeat after half a second
+func keyRepeated(key ebiten.Key) bool {
+	const repeatDelay = 30
+	const repeatInterval = 3
+
+	d := inpututil.KeyPressDuration(key)
+	return d == 1 || (d >= repeatDelay && (d-repeatDelay)%repeatInterval == 0)
+}
+
+// The input of this update for the widgets of a window at the origin,
+// the mouse being ignored when mLeft was cleared for another window
+func newUIInput(mPos point, mLeft bool, origin point, font *Font) *ui.Input {
+	rel := mPos.sub(origin)
+	in := &ui.Input{
+		X: rel[0], Y: rel[1],
+		Pressed: mLeft,
+		Down:    ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft),
+		Shift:   ebiten.IsKeyPressed(ebiten.KeyShift),
+		Chars:   ebiten.AppendInputChars(nil),
+		Metrics: widgetPainter{font: font},
+	}
+	_, in.Wheel = ebiten.Wheel()
+	for _, k := range uiKeys {
+		if (k.repeat && keyRepeated(k.key)) || (!k.repeat && inpututil.IsKeyJustPressed(k.key)) {
+			in.Keys = append(in.Keys, k.uiKey)
+		}
+	}
+	return in
+}